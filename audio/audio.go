@@ -1,72 +1,86 @@
 package audio
 
 import (
-	"bytes"
-	"encoding/binary"
 	"log"
+	"math"
 
-	"github.com/AchrafSoltani/glow"
+	"github.com/AchrafSoltani/TankStrike/config"
 )
 
-// Engine manages audio playback using Glow's PulseAudio backend.
+const (
+	// panWidth is how far to the side of the listener, in play-area
+	// pixels, a sound has to be to play from one speaker only.
+	panWidth = config.PlayAreaWidth / 2
+
+	// falloff is how much quieter a sound is at falloffDistance or
+	// more from the listener.
+	falloff         = 0.6
+	falloffDistance = config.PlayAreaWidth
+)
+
+// Engine plays the game's audio through an Output.
 type Engine struct {
-	ctx *glow.AudioContext
-
-	// Pre-generated sound buffers
-	shootBuf     []byte
-	explodeBuf   []byte
-	powerUpBuf   []byte
-	gameOverBuf  []byte
-	levelBuf     []byte
-	menuSelBuf   []byte
-
-	Muted  bool
-	Volume float64
-}
-
-// NewEngine initialises the audio subsystem.
-func NewEngine() *Engine {
-	ctx, err := glow.NewAudioContext(sampleRate, 1, 2) // sampleRate, mono, 16-bit
-	if err != nil {
-		log.Printf("audio: failed to init audio: %v", err)
-		return &Engine{}
-	}
+	out       Output
+	mixer     *Mixer
+	tankLoops [3]bool // idle, moving, sliding, as last set
+
+	Muted       bool
+	Volume      float64 // sound effects
+	MusicVolume float64
+
+	// Where in the play area in-world sounds are heard from.
+	ListenerX, ListenerY float64
 
+	// OnPlay, if set, is called with every sound effect started, at the
+	// gain and pan it is played with. Tests use it to see what fired.
+	OnPlay func(s Sound, gain, pan float64)
+}
+
+// NewEngine initialises the audio subsystem, playing through out. If out
+// fails to start, the engine carries on with a NullOutput.
+func NewEngine(out Output) *Engine {
 	e := &Engine{
-		ctx:         ctx,
-		shootBuf:    GenerateShoot(),
-		explodeBuf:  GenerateExplode(),
-		powerUpBuf:  GeneratePowerUp(),
-		gameOverBuf: GenerateGameOver(),
-		levelBuf:    GenerateLevelStart(),
-		menuSelBuf:  GenerateMenuSelect(),
+		out:         out,
 		Volume:      1.0,
+		MusicVolume: 0.7,
+		ListenerX:   config.PlayAreaWidth / 2,
+		ListenerY:   config.PlayAreaHeight / 2,
+	}
+	e.mixer = NewMixer(DefaultEffects(), e.Volume, e.MusicVolume)
+	if err := out.Play(e.mixer); err != nil {
+		log.Printf("audio: failed to init audio, playing none: %v", err)
+		e.out = NullOutput{}
 	}
 	return e
 }
 
-func (e *Engine) play(buf []byte) {
-	if e.ctx == nil || len(buf) == 0 || e.Muted {
-		return
+// LoadEffects plays the sound effect definitions in dir in place of the
+// built-in ones. Sounds without a file in dir keep their built-in sound.
+func (e *Engine) LoadEffects(dir string) error {
+	fx := DefaultEffects()
+	if err := fx.LoadDir(dir); err != nil {
+		return err
 	}
+	e.mixer.SetEffects(fx)
+	return nil
+}
 
-	scaled := buf
-	if e.Volume < 1.0 {
-		scaled = make([]byte, len(buf))
-		for i := 0; i+1 < len(buf); i += 2 {
-			sample := int16(binary.LittleEndian.Uint16(buf[i:]))
-			sample = int16(float64(sample) * e.Volume)
-			binary.LittleEndian.PutUint16(scaled[i:], uint16(sample))
-		}
+// Close stops the output, finishing any recording, and then the mixer.
+func (e *Engine) Close() {
+	if err := e.out.Close(); err != nil {
+		log.Printf("audio: %v", err)
 	}
+	e.mixer.Close()
+}
 
-	p := e.ctx.NewPlayer(bytes.NewReader(scaled))
-	p.Play()
+func (e *Engine) setVolume() {
+	e.mixer.SetVolume(e.Volume, e.MusicVolume, e.Muted)
 }
 
 // ToggleMute toggles the muted state.
 func (e *Engine) ToggleMute() {
 	e.Muted = !e.Muted
+	e.setVolume()
 }
 
 // VolumeUp increases volume by 0.1, capped at 1.0.
@@ -75,6 +89,7 @@ func (e *Engine) VolumeUp() {
 	if e.Volume > 1.0 {
 		e.Volume = 1.0
 	}
+	e.setVolume()
 }
 
 // VolumeDown decreases volume by 0.1, floored at 0.0.
@@ -83,22 +98,117 @@ func (e *Engine) VolumeDown() {
 	if e.Volume < 0.0 {
 		e.Volume = 0.0
 	}
+	e.setVolume()
+}
+
+// MusicVolumeUp increases the music volume by 0.1, capped at 1.0.
+func (e *Engine) MusicVolumeUp() {
+	e.MusicVolume = min(e.MusicVolume+0.1, 1.0)
+	e.setVolume()
 }
 
-// PlayShoot plays the shooting sound.
-func (e *Engine) PlayShoot() { e.play(e.shootBuf) }
+// MusicVolumeDown decreases the music volume by 0.1, floored at 0.0.
+func (e *Engine) MusicVolumeDown() {
+	e.MusicVolume = max(e.MusicVolume-0.1, 0.0)
+	e.setVolume()
+}
+
+// PlayMusic switches to track, starting it from the top. Asking for the
+// track already playing leaves it be.
+func (e *Engine) PlayMusic(t Track) { e.mixer.PlayMusic(t) }
+
+// PauseMusic holds the current track where it is, or lets it carry on.
+func (e *Engine) PauseMusic(paused bool) { e.mixer.PauseMusic(paused) }
 
-// PlayExplode plays the explosion sound.
-func (e *Engine) PlayExplode() { e.play(e.explodeBuf) }
+// SetListener moves where in-world sounds are heard from to play-area
+// position x, y.
+func (e *Engine) SetListener(x, y float64) {
+	e.ListenerX, e.ListenerY = x, y
+}
+
+// playAt plays s as heard from the listener: panned toward the side it is
+// on and quieter the further away it is.
+func (e *Engine) playAt(s Sound, x, y float64) {
+	dx, dy := x-e.ListenerX, y-e.ListenerY
+	pan := max(-1, min(dx/panWidth, 1))
+	gain := 1 - falloff*min(math.Hypot(dx, dy)/falloffDistance, 1)
+	e.start(s, gain, pan)
+}
+
+// play plays s from no particular place.
+func (e *Engine) play(s Sound) {
+	e.start(s, 1, 0)
+}
+
+func (e *Engine) start(s Sound, gain, pan float64) {
+	if e.OnPlay != nil {
+		e.OnPlay(s, gain, pan)
+	}
+	e.mixer.Play(s, gain, pan)
+}
+
+// PlayShoot plays the shooting sound of a shot fired at x, y.
+func (e *Engine) PlayShoot(x, y float64) { e.playAt(SoundShoot, x, y) }
+
+// PlayEnemyShoot plays the sound of an enemy shot fired at x, y.
+func (e *Engine) PlayEnemyShoot(x, y float64) { e.playAt(SoundEnemyShoot, x, y) }
+
+// PlayExplode plays the sound of an explosion at x, y.
+func (e *Engine) PlayExplode(x, y float64) { e.playAt(SoundExplode, x, y) }
+
+// PlaySteelHit plays the clank of a bullet hitting steel at x, y.
+func (e *Engine) PlaySteelHit(x, y float64) { e.playAt(SoundSteelHit, x, y) }
+
+// PlayBrickCrumble plays the sound of brick knocked out at x, y.
+func (e *Engine) PlayBrickCrumble(x, y float64) { e.playAt(SoundBrickCrumble, x, y) }
+
+// PlayArmourHit plays the sound of an armoured tank at x, y shrugging off
+// a hit.
+func (e *Engine) PlayArmourHit(x, y float64) { e.playAt(SoundArmourHit, x, y) }
+
+// PlayEnemySpawn plays the sound of an enemy appearing at x, y.
+func (e *Engine) PlayEnemySpawn(x, y float64) { e.playAt(SoundEnemySpawn, x, y) }
+
+// PlayPowerUpAppear plays the sound of a power-up appearing at x, y.
+func (e *Engine) PlayPowerUpAppear(x, y float64) { e.playAt(SoundPowerUpAppear, x, y) }
+
+// PlayRespawn plays the sound of a player's tank respawning at x, y.
+func (e *Engine) PlayRespawn(x, y float64) { e.playAt(SoundRespawn, x, y) }
+
+// PlayShieldWarning plays the warning that the shield of the tank at x, y
+// is about to run out.
+func (e *Engine) PlayShieldWarning(x, y float64) { e.playAt(SoundShieldWarning, x, y) }
+
+// PlayFortifyEnd plays the sound of the eagle's steel reverting to brick.
+func (e *Engine) PlayFortifyEnd() { e.play(SoundFortifyEnd) }
+
+// SetTankLoops sets which of the player's tank sounds loop: the engine
+// idling or moving, and the treads sliding on ice.
+func (e *Engine) SetTankLoops(idle, moving, sliding bool) {
+	if e.tankLoops == [3]bool{idle, moving, sliding} {
+		return
+	}
+	e.tankLoops = [3]bool{idle, moving, sliding}
+	e.mixer.Loop(SoundEngineIdle, loopGain(idle))
+	e.mixer.Loop(SoundEngineMove, loopGain(moving))
+	e.mixer.Loop(SoundIceSlide, loopGain(sliding))
+}
+
+func loopGain(on bool) float64 {
+	if on {
+		return 1
+	}
+	return 0
+}
 
 // PlayPowerUp plays the power-up collection sound.
-func (e *Engine) PlayPowerUp() { e.play(e.powerUpBuf) }
+func (e *Engine) PlayPowerUp() { e.play(SoundPowerUp) }
 
 // PlayGameOver plays the game over sound.
-func (e *Engine) PlayGameOver() { e.play(e.gameOverBuf) }
+func (e *Engine) PlayGameOver() { e.play(SoundGameOver) }
 
 // PlayLevelStart plays the level start fanfare.
-func (e *Engine) PlayLevelStart() { e.play(e.levelBuf) }
+func (e *Engine) PlayLevelStart() { e.play(SoundLevelStart) }
 
 // PlayMenuSelect plays the menu selection blip.
-func (e *Engine) PlayMenuSelect() { e.play(e.menuSelBuf) }
+func (e *Engine) PlayMenuSelect() { e.play(SoundMenuSelect) }
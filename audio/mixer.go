@@ -0,0 +1,403 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	// blockSamples is how much audio the mixer renders at a time, and
+	// queuedBlocks how many rendered blocks may wait for the backend.
+	// Together they bound the delay between a Play call and the sound.
+	blockSamples = 512
+	queuedBlocks = 2
+
+	// maxVoices is how many sounds may play at once across every type.
+	// Loops do not count.
+	maxVoices = 16
+
+	// loopFade is how many seconds a loop takes to fade fully in or out.
+	loopFade = 0.05
+
+	// limiterKnee is the level above which the limiter starts to bend the
+	// mix down, so that it approaches full scale without ever clipping.
+	limiterKnee = 0.6
+
+	// musicGain is the level of music at full volume, kept under the
+	// sound effects.
+	musicGain = 0.18
+)
+
+// Sound is one of the game's sound effects.
+type Sound int
+
+const (
+	SoundShoot Sound = iota
+	SoundEnemyShoot
+	SoundExplode
+	SoundPowerUp
+	SoundGameOver
+	SoundLevelStart
+	SoundMenuSelect
+	SoundSteelHit
+	SoundBrickCrumble
+	SoundArmourHit
+	SoundEnemySpawn
+	SoundPowerUpAppear
+	SoundShieldWarning
+	SoundFortifyEnd
+	SoundRespawn
+
+	// Loops, played with Loop rather than Play.
+	SoundEngineIdle
+	SoundEngineMove
+	SoundIceSlide
+
+	soundCount
+)
+
+// soundSpec is how the mixer treats a sound: how many copies of it may
+// play at once, and which sounds it may cut off when every voice is busy.
+// A sound can steal a voice from one of equal or lower priority.
+type soundSpec struct {
+	maxVoices int
+	priority  int
+}
+
+var soundSpecs = [soundCount]soundSpec{
+	SoundShoot:      {4, 1},
+	SoundEnemyShoot: {4, 0},
+	SoundExplode:    {3, 2},
+	SoundPowerUp:    {1, 3},
+	SoundGameOver:   {1, 4},
+	SoundLevelStart: {1, 4},
+	SoundMenuSelect: {2, 1},
+
+	SoundSteelHit:      {3, 1},
+	SoundBrickCrumble:  {3, 1},
+	SoundArmourHit:     {2, 2},
+	SoundEnemySpawn:    {2, 1},
+	SoundPowerUpAppear: {1, 3},
+	SoundShieldWarning: {1, 3},
+	SoundFortifyEnd:    {1, 3},
+	SoundRespawn:       {2, 2},
+}
+
+// voice is one sound playing.
+type voice struct {
+	sound   Sound
+	samples []float32
+	pos     int
+	left    float32 // gain in each ear
+	right   float32
+	started uint64 // when it started, in voices started, for stealing the oldest
+}
+
+// loop is a sound that repeats for as long as its gain is above zero.
+type loop struct {
+	pos    int
+	gain   float64
+	target float64
+}
+
+// Mixer sums every playing sound and the music into one stream on its own
+// goroutine. The game talks to it only through commands, so nothing it
+// does waits on the backend, which reads the mix through the Mixer's Read.
+type Mixer struct {
+	cmds   chan func(*Mixer)
+	blocks chan []byte
+	quit   chan struct{}
+	block  []byte // what is left of the block being read
+
+	closeOnce sync.Once
+
+	// Owned by the mixer goroutine.
+	samples     [soundCount][]float32
+	voices      []voice
+	loops       [soundCount]loop
+	started     uint64
+	volume      float64
+	musicVolume float64
+	muted       bool
+	track       Track
+	seq         *Sequencer
+	paused      bool
+	mix         [][2]float64             // left, right
+	out         [queuedBlocks + 2][]byte // blocks queued, being read and being rendered
+	next        int
+}
+
+// NewMixer starts a mixer playing the given effects at the given effect
+// and music volumes.
+func NewMixer(fx Effects, volume, musicVolume float64) *Mixer {
+	m := &Mixer{
+		cmds:        make(chan func(*Mixer), 64),
+		blocks:      make(chan []byte, queuedBlocks),
+		quit:        make(chan struct{}),
+		voices:      make([]voice, 0, maxVoices),
+		volume:      volume,
+		musicVolume: musicVolume,
+		mix:         make([][2]float64, blockSamples),
+	}
+	for i := range m.out {
+		m.out[i] = make([]byte, blockSamples*4)
+	}
+	m.samples = fx.render()
+	go m.run()
+	return m
+}
+
+// send queues a sound for the mixer goroutine. It never blocks: if the
+// mixer has fallen that far behind, the sound is dropped. It does nothing
+// on a nil mixer, which is what an engine without audio has.
+func (m *Mixer) send(cmd func(*Mixer)) {
+	if m == nil {
+		return
+	}
+	select {
+	case m.cmds <- cmd:
+	default:
+	}
+}
+
+// sendState queues a change to the mixer's state, which unlike a sound
+// must not be lost. It waits for room in the queue, which the goroutine
+// drains even while the backend is not reading, and gives up only once
+// the mixer is closed.
+func (m *Mixer) sendState(cmd func(*Mixer)) {
+	if m == nil {
+		return
+	}
+	select {
+	case m.cmds <- cmd:
+	case <-m.quit:
+	}
+}
+
+// Play starts sound s at the given loudness, from 0 to 1, and stereo
+// position, from -1 (left) to 1 (right). Loops are ignored; they play
+// through Loop.
+func (m *Mixer) Play(s Sound, gain, pan float64) {
+	if soundSpecs[s].maxVoices == 0 {
+		return
+	}
+	m.send(func(m *Mixer) { m.start(s, gain, pan) })
+}
+
+// Loop plays sound s over and over at the given gain, fading to it
+// rather than jumping. A gain of 0 fades it out.
+func (m *Mixer) Loop(s Sound, gain float64) {
+	m.sendState(func(m *Mixer) { m.loops[s].target = gain })
+}
+
+// SetEffects switches to playing fx. Sounds already playing finish as
+// they were.
+func (m *Mixer) SetEffects(fx Effects) {
+	samples := fx.render()
+	m.sendState(func(m *Mixer) {
+		m.samples = samples
+		for s := range m.loops {
+			m.loops[s].pos = 0
+		}
+	})
+}
+
+// SetVolume sets the effect and music volumes and whether all audio is
+// muted.
+func (m *Mixer) SetVolume(volume, musicVolume float64, muted bool) {
+	m.sendState(func(m *Mixer) {
+		m.volume, m.musicVolume, m.muted = volume, musicVolume, muted
+	})
+}
+
+// PlayMusic switches to track, starting it from the top. Asking for the
+// track already playing leaves it be.
+func (m *Mixer) PlayMusic(t Track) {
+	m.sendState(func(m *Mixer) {
+		if m.track == t {
+			return
+		}
+		m.track = t
+		m.seq = nil
+		if song := songs[t]; song != nil {
+			m.seq = NewSequencer(song)
+		}
+	})
+}
+
+// PauseMusic holds the current track where it is, or lets it carry on.
+func (m *Mixer) PauseMusic(paused bool) {
+	m.sendState(func(m *Mixer) { m.paused = paused })
+}
+
+// Close stops the mixer goroutine; reads after it return io.EOF. Calls
+// after the first do nothing.
+func (m *Mixer) Close() {
+	if m != nil {
+		m.closeOnce.Do(func() { close(m.quit) })
+	}
+}
+
+// Read reads 16-bit stereo frames of the mix.
+func (m *Mixer) Read(p []byte) (int, error) {
+	if len(m.block) == 0 {
+		select {
+		case m.block = <-m.blocks:
+		case <-m.quit:
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, m.block)
+	m.block = m.block[n:]
+	return n, nil
+}
+
+func (m *Mixer) run() {
+	for {
+		block := m.render()
+		for sent := false; !sent; {
+			select {
+			case m.blocks <- block:
+				sent = true
+			case cmd := <-m.cmds:
+				cmd(m)
+			case <-m.quit:
+				return
+			}
+		}
+	}
+}
+
+// start gives sound s a voice, panned with a balance law: the centre
+// plays at full gain in both ears and each side fades out the other. If s
+// already has all the voices it may, its oldest is restarted; if every
+// voice is busy, the oldest of the lowest priority sounds is cut off, as
+// long as it matters no more than s.
+func (m *Mixer) start(s Sound, gain, pan float64) {
+	spec := soundSpecs[s]
+	v := voice{
+		sound:   s,
+		samples: m.samples[s],
+		left:    float32(gain * min(1, 1-pan)),
+		right:   float32(gain * min(1, 1+pan)),
+		started: m.started,
+	}
+	m.started++
+
+	same, victim := 0, -1
+	for i, o := range m.voices {
+		if o.sound == s {
+			same++
+			if victim < 0 || o.started < m.voices[victim].started {
+				victim = i
+			}
+		}
+	}
+	if same >= spec.maxVoices {
+		m.voices[victim] = v
+		return
+	}
+	if len(m.voices) < maxVoices {
+		m.voices = append(m.voices, v)
+		return
+	}
+
+	victim = -1
+	for i, o := range m.voices {
+		p := soundSpecs[o.sound].priority
+		if p > spec.priority {
+			continue
+		}
+		if victim < 0 {
+			victim = i
+			continue
+		}
+		vp := soundSpecs[m.voices[victim].sound].priority
+		if p < vp || p == vp && o.started < m.voices[victim].started {
+			victim = i
+		}
+	}
+	if victim >= 0 {
+		m.voices[victim] = v
+	}
+}
+
+// render mixes the next block.
+func (m *Mixer) render() []byte {
+	clear(m.mix)
+
+	if m.seq != nil && !m.paused {
+		for i := range m.mix {
+			v := m.seq.Next() * musicGain * m.musicVolume
+			m.mix[i] = [2]float64{v, v}
+		}
+	}
+
+	step := 1 / (loopFade * sampleRate)
+	for s := range m.loops {
+		l := &m.loops[s]
+		samples := m.samples[s]
+		if l.gain == 0 && l.target == 0 || len(samples) == 0 {
+			continue
+		}
+		for i := range m.mix {
+			l.gain += max(-step, min(l.target-l.gain, step))
+			v := float64(samples[l.pos]) * l.gain * m.volume
+			m.mix[i][0] += v
+			m.mix[i][1] += v
+			l.pos = (l.pos + 1) % len(samples)
+		}
+	}
+
+	n := 0
+	for _, v := range m.voices {
+		k := min(len(m.mix), len(v.samples)-v.pos)
+		left, right := float64(v.left)*m.volume, float64(v.right)*m.volume
+		for i, s := range v.samples[v.pos : v.pos+k] {
+			m.mix[i][0] += float64(s) * left
+			m.mix[i][1] += float64(s) * right
+		}
+		v.pos += k
+		if v.pos < len(v.samples) {
+			m.voices[n] = v
+			n++
+		}
+	}
+	m.voices = m.voices[:n]
+
+	out := m.out[m.next]
+	m.next = (m.next + 1) % len(m.out)
+	for i, frame := range m.mix {
+		for ch, x := range frame {
+			var sample int16
+			if !m.muted {
+				sample = int16(limit(x) * math.MaxInt16)
+			}
+			binary.LittleEndian.PutUint16(out[i*4+ch*2:], uint16(sample))
+		}
+	}
+	return out
+}
+
+// limit passes quiet samples through untouched and bends louder ones
+// smoothly toward full scale, so stacked sounds get denser rather than
+// clipping.
+func limit(x float64) float64 {
+	a := math.Abs(x)
+	if a <= limiterKnee {
+		return x
+	}
+	a = limiterKnee + (1-limiterKnee)*math.Tanh((a-limiterKnee)/(1-limiterKnee))
+	return math.Copysign(a, x)
+}
+
+// render synthesises every effect.
+func (fx *Effects) render() [soundCount][]float32 {
+	var samples [soundCount][]float32
+	for s, e := range fx {
+		samples[s] = e.Render()
+	}
+	return samples
+}
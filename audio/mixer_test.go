@@ -0,0 +1,21 @@
+package audio
+
+import "testing"
+
+func TestMixerPlayIgnoresLoops(t *testing.T) {
+	m := &Mixer{cmds: make(chan func(*Mixer), 1)}
+	m.Play(SoundEngineIdle, 1, 0)
+	if len(m.cmds) != 0 {
+		t.Fatal("Play queued a loop sound")
+	}
+	m.Play(SoundShoot, 1, 0)
+	if len(m.cmds) != 1 {
+		t.Fatal("Play did not queue a one-shot sound")
+	}
+}
+
+func TestMixerCloseTwice(t *testing.T) {
+	m := NewMixer(DefaultEffects(), 1, 1)
+	m.Close()
+	m.Close()
+}
@@ -0,0 +1,244 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Wave is the shape a channel or effect plays. Music sticks to the first
+// three, after the NES APU's channels.
+type Wave int
+
+const (
+	WaveSquare   Wave = iota // pulse wave with a selectable duty cycle
+	WaveTriangle             // 32-step quantised triangle, for bass
+	WaveNoise                // 15-bit LFSR noise, for drums
+	WaveSine
+	WaveSaw
+)
+
+// Note is one row of one channel: a MIDI note number to strike, Hold to
+// keep sounding whatever is playing, or Rest to release it. On a noise
+// channel the note number sets how quickly the noise is clocked.
+type Note int
+
+const (
+	Hold Note = 0
+	Rest Note = -1
+)
+
+// Voice is how a channel sounds.
+type Voice struct {
+	Wave    Wave
+	Duty    float64 // fraction of a square wave's period spent high
+	Volume  float64 // 0-1, share of the mix
+	Decay   float64 // envelope fall per second after a note is struck
+	Sustain float64 // level the envelope decays to
+}
+
+// Pattern is a block of rows, one row list per channel, all the same length.
+type Pattern [][]Note
+
+// Song is a tracker-style tune: patterns played in order at a fixed tempo.
+type Song struct {
+	Tempo       float64 // beats per minute
+	RowsPerBeat int
+	Voices      []Voice
+	Patterns    []Pattern
+	Order       []int // patterns to play, by index
+	Loop        int   // position in Order to go back to after the last; -1 plays once
+}
+
+// releaseRate is how quickly a rested note fades, fast enough to sound
+// like a stop but slow enough not to click.
+const releaseRate = 40.0
+
+// channel is the playing state of one voice.
+type channel struct {
+	Voice
+	freq      float64
+	phase     float64
+	env       float64
+	releasing bool
+	lfsr      uint16
+}
+
+func (c *channel) strike(n Note) {
+	switch {
+	case n == Rest:
+		c.releasing = true
+	case n > 0:
+		c.freq = noteFreq(n)
+		if c.Wave == WaveNoise {
+			// Clock the noise well above the note so it hisses rather
+			// than buzzes; low notes still rumble.
+			c.freq *= 8
+		}
+		c.env = 1
+		c.releasing = false
+	}
+}
+
+func (c *channel) next() float64 {
+	if c.releasing {
+		c.env = math.Max(0, c.env-releaseRate/sampleRate)
+	} else if c.env > c.Sustain {
+		c.env = math.Max(c.Sustain, c.env-c.Decay/sampleRate)
+	}
+	if c.env == 0 || c.freq == 0 {
+		return 0
+	}
+
+	c.phase += c.freq / sampleRate
+	var v float64
+	switch c.Wave {
+	case WaveSquare:
+		c.phase -= math.Floor(c.phase)
+		v = -1
+		if c.phase < c.Duty {
+			v = 1
+		}
+	case WaveTriangle:
+		c.phase -= math.Floor(c.phase)
+		step := int(c.phase * 32)
+		if step >= 16 {
+			step = 31 - step
+		}
+		v = float64(step)/7.5 - 1
+	case WaveNoise:
+		for c.phase >= 1 {
+			c.phase--
+			bit := (c.lfsr ^ c.lfsr>>1) & 1
+			c.lfsr = c.lfsr>>1 | bit<<14
+		}
+		v = float64(c.lfsr&1)*2 - 1
+	case WaveSine:
+		c.phase -= math.Floor(c.phase)
+		v = math.Sin(2 * math.Pi * c.phase)
+	case WaveSaw:
+		c.phase -= math.Floor(c.phase)
+		v = 2*c.phase - 1
+	}
+	return v * c.env * c.Volume
+}
+
+// Sequencer renders a song one sample at a time.
+type Sequencer struct {
+	song     *Song
+	channels []channel
+	pos      int // position in song.Order
+	row      int
+	left     int // samples left in the current row
+	rowLen   int
+	done     bool
+}
+
+// NewSequencer returns a sequencer at the start of song.
+func NewSequencer(song *Song) *Sequencer {
+	s := &Sequencer{
+		song:     song,
+		channels: make([]channel, len(song.Voices)),
+		rowLen:   int(sampleRate * 60 / (song.Tempo * float64(song.RowsPerBeat))),
+	}
+	for i, v := range song.Voices {
+		s.channels[i] = channel{Voice: v, lfsr: 1}
+	}
+	return s
+}
+
+// Done reports whether a song that does not loop has finished.
+func (s *Sequencer) Done() bool {
+	return s.done
+}
+
+// Next returns the next sample, between -1 and 1.
+func (s *Sequencer) Next() float64 {
+	if s.done {
+		return 0
+	}
+	if s.left == 0 {
+		s.advance()
+		if s.done {
+			return 0
+		}
+	}
+	s.left--
+
+	var v float64
+	for i := range s.channels {
+		v += s.channels[i].next()
+	}
+	return v
+}
+
+// advance strikes the notes of the next row.
+func (s *Sequencer) advance() {
+	p := s.song.Patterns[s.song.Order[s.pos]]
+	if s.row == len(p[0]) {
+		s.row = 0
+		s.pos++
+		if s.pos == len(s.song.Order) {
+			if s.song.Loop < 0 {
+				s.done = true
+				return
+			}
+			s.pos = s.song.Loop
+		}
+		p = s.song.Patterns[s.song.Order[s.pos]]
+	}
+	for i, rows := range p {
+		s.channels[i].strike(rows[s.row])
+	}
+	s.row++
+	s.left = s.rowLen
+}
+
+// noteFreq returns the frequency of MIDI note n.
+func noteFreq(n Note) float64 {
+	return 440 * math.Pow(2, float64(n-69)/12)
+}
+
+var noteNames = map[byte]Note{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+// parseNote reads a note such as "C4", "F#3" or "Bb5", or "." for Hold and
+// "-" for Rest.
+func parseNote(tok string) (Note, error) {
+	switch tok {
+	case ".":
+		return Hold, nil
+	case "-":
+		return Rest, nil
+	}
+	n, ok := noteNames[tok[0]]
+	if !ok || len(tok) < 2 {
+		return 0, fmt.Errorf("bad note %q", tok)
+	}
+	rest := tok[1:]
+	switch rest[0] {
+	case '#':
+		n++
+		rest = rest[1:]
+	case 'b':
+		n--
+		rest = rest[1:]
+	}
+	if len(rest) != 1 || rest[0] < '0' || rest[0] > '9' {
+		return 0, fmt.Errorf("bad octave in note %q", tok)
+	}
+	return n + Note(rest[0]-'0'+1)*12, nil
+}
+
+// Notes parses a space-separated row list such as "C4 . E4 - G4". It
+// panics on a malformed note, since songs are built from constants.
+func Notes(rows string) []Note {
+	var notes []Note
+	for _, tok := range strings.Fields(rows) {
+		n, err := parseNote(tok)
+		if err != nil {
+			panic("audio: " + err.Error())
+		}
+		notes = append(notes, n)
+	}
+	return notes
+}
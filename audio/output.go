@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AchrafSoltani/glow"
+)
+
+// Output is where the mix goes: 16-bit stereo frames at sampleRate.
+type Output interface {
+	// Play starts pulling the mix from r for as long as the output is open.
+	Play(r io.Reader) error
+	Close() error
+}
+
+// OpenOutput opens the output named by spec: "glow" for the speakers
+// through Glow's PulseAudio backend, "null" for none, or the path of a
+// .wav file to record the session's audio to.
+func OpenOutput(spec string) (Output, error) {
+	switch {
+	case spec == "glow":
+		return &GlowOutput{}, nil
+	case spec == "null":
+		return NullOutput{}, nil
+	case strings.HasSuffix(spec, ".wav"):
+		return NewWAVOutput(spec)
+	}
+	return nil, fmt.Errorf("unknown audio output %q: want glow, null or a .wav file", spec)
+}
+
+// GlowOutput plays the mix on the speakers.
+type GlowOutput struct {
+	ctx *glow.AudioContext
+}
+
+func (o *GlowOutput) Play(r io.Reader) error {
+	ctx, err := glow.NewAudioContext(sampleRate, 2, 2) // sampleRate, stereo, 16-bit
+	if err != nil {
+		return err
+	}
+	o.ctx = ctx
+	ctx.NewPlayer(r).Play()
+	return nil
+}
+
+func (o *GlowOutput) Close() error {
+	if o.ctx == nil {
+		return nil
+	}
+	err := o.ctx.Close()
+	o.ctx = nil
+	return err
+}
+
+// NullOutput discards the mix without reading it, for running without a
+// sound device.
+type NullOutput struct{}
+
+func (NullOutput) Play(io.Reader) error { return nil }
+func (NullOutput) Close() error         { return nil }
+
+// WAVOutput records the mix to a WAV file, pulling it at the rate a sound
+// card would so that the recording keeps time with the session.
+type WAVOutput struct {
+	f    *os.File
+	quit chan struct{}
+	wg   sync.WaitGroup
+	n    int64 // bytes of audio written
+	err  error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewWAVOutput creates the WAV file at path.
+func NewWAVOutput(path string) (*WAVOutput, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	// The sizes in the header are filled in on Close.
+	if err := WriteWAV(f, nil, 2); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &WAVOutput{f: f, quit: make(chan struct{})}, nil
+}
+
+// wavPoll is how often the WAV output catches up with the clock.
+const wavPoll = 10 * time.Millisecond
+
+func (o *WAVOutput) Play(r io.Reader) error {
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		start := time.Now()
+		ticker := time.NewTicker(wavPoll)
+		defer ticker.Stop()
+		buf := make([]byte, 4096)
+		for {
+			select {
+			case <-o.quit:
+				return
+			case <-ticker.C:
+			}
+			due := int64(time.Since(start).Seconds()*sampleRate)*4 - o.n
+			for due > 0 {
+				k, err := io.ReadFull(r, buf[:min(due, int64(len(buf)))])
+				if _, werr := o.f.Write(buf[:k]); werr != nil && err == nil {
+					err = werr
+				}
+				o.n += int64(k)
+				due -= int64(k)
+				if err != nil {
+					o.err = err
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops recording and finishes the file. Calls after the first
+// return the first's result.
+func (o *WAVOutput) Close() error {
+	o.closeOnce.Do(func() { o.closeErr = o.finish() })
+	return o.closeErr
+}
+
+func (o *WAVOutput) finish() error {
+	close(o.quit)
+	o.wg.Wait()
+	err := o.err
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	var size [4]byte
+	for _, field := range []struct{ off, v int64 }{{4, 36 + o.n}, {40, o.n}} {
+		binary.LittleEndian.PutUint32(size[:], uint32(field.v))
+		if _, werr := o.f.WriteAt(size[:], field.off); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
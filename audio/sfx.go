@@ -0,0 +1,403 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const sampleRate = 44100
+
+// Sound effect files (.sfx) describe an effect in the spirit of sfxr: a
+// versioned header, then "key: value" lines. Blank lines and lines
+// starting with '#' are ignored, and every field is optional.
+//
+//	tankstrike-sfx 1
+//	wave: sine           (sine, square, triangle, saw or noise)
+//	frequency: 800       (Hz at the start)
+//	slide: -4000         (Hz per second)
+//	slide-accel: 0       (Hz per second, per second)
+//	min-frequency: 20    (the slide stops here)
+//	steps: 1 1.5 2       (frequency multipliers played in turn, like an arpeggio)
+//	duty: 0.5            (share of each cycle spent high, 0-1; square wave only)
+//	vibrato: 0.05 6      (depth as a share of the frequency, 0-1, speed in Hz)
+//	overtone: 2 0.3      (frequency ratio and level, 0-1, of a sine layered on top)
+//	noise: 0.5           (share of noise mixed with the tone, 0-1)
+//	attack: 0            (seconds to rise to full level)
+//	decay: 0             (seconds to fall to the sustain level)
+//	sustain: 1           (level held after the decay, 0-1)
+//	hold: 0              (seconds at the sustain level)
+//	release: 0.1         (seconds to fall silent)
+//	curve: 2             (envelope exponent above 0; above 1 is punchier)
+//	volume: 0.25         (peak level, 0-1)
+//
+// Only slide and slide-accel may be negative. The effect lasts attack +
+// decay + hold + release, which may come to no more than maxEffectDuration.
+const (
+	EffectFileMagic   = "tankstrike-sfx"
+	EffectFileVersion = 1
+	EffectFileExt     = ".sfx"
+)
+
+// maxEffectDuration is the longest an effect may last, in seconds.
+const maxEffectDuration = 10
+
+// Effect is a sound effect definition.
+type Effect struct {
+	Name         string
+	Wave         Wave
+	Frequency    float64
+	Slide        float64
+	SlideAccel   float64
+	MinFrequency float64
+	Steps        []float64
+	Duty         float64
+	VibratoDepth float64
+	VibratoSpeed float64
+	Overtone     float64 // frequency ratio, 0 for none
+	OvertoneMix  float64
+	Noise        float64
+	Attack       float64
+	Decay        float64
+	Sustain      float64
+	Hold         float64
+	Release      float64
+	Curve        float64
+	Volume       float64
+}
+
+var waveNames = map[string]Wave{
+	"square":   WaveSquare,
+	"triangle": WaveTriangle,
+	"noise":    WaveNoise,
+	"sine":     WaveSine,
+	"saw":      WaveSaw,
+}
+
+// soundNames are the file names, without extension, of each sound's
+// definition.
+var soundNames = [soundCount]string{
+	SoundShoot:      "shoot",
+	SoundEnemyShoot: "enemy-shoot",
+	SoundExplode:    "explode",
+	SoundPowerUp:    "power-up",
+	SoundGameOver:   "game-over",
+	SoundLevelStart: "level-start",
+	SoundMenuSelect: "menu-select",
+
+	SoundSteelHit:      "steel-hit",
+	SoundBrickCrumble:  "brick-crumble",
+	SoundArmourHit:     "armour-hit",
+	SoundEnemySpawn:    "enemy-spawn",
+	SoundPowerUpAppear: "power-up-appear",
+	SoundShieldWarning: "shield-warning",
+	SoundFortifyEnd:    "fortify-end",
+	SoundRespawn:       "respawn",
+	SoundEngineIdle:    "engine-idle",
+	SoundEngineMove:    "engine-move",
+	SoundIceSlide:      "ice-slide",
+}
+
+func (s Sound) String() string {
+	if s < 0 || s >= soundCount {
+		return fmt.Sprintf("Sound(%d)", int(s))
+	}
+	return soundNames[s]
+}
+
+// SoundNamed returns the sound whose definition is called name.
+func SoundNamed(name string) (Sound, bool) {
+	for s, n := range soundNames {
+		if n == name {
+			return Sound(s), true
+		}
+	}
+	return 0, false
+}
+
+// Duration returns how long the effect lasts, in seconds.
+func (e *Effect) Duration() float64 {
+	return e.Attack + e.Decay + e.Hold + e.Release
+}
+
+// envelope returns the level t seconds in.
+func (e *Effect) envelope(t float64) float64 {
+	var env float64
+	switch {
+	case t < e.Attack:
+		env = t / e.Attack
+	case t < e.Attack+e.Decay:
+		env = 1 - (1-e.Sustain)*(t-e.Attack)/e.Decay
+	case t < e.Attack+e.Decay+e.Hold:
+		env = e.Sustain
+	case e.Release > 0:
+		env = e.Sustain * max(0, 1-(t-e.Attack-e.Decay-e.Hold)/e.Release)
+	}
+	return math.Pow(env, e.Curve)
+}
+
+// Render synthesises the effect as samples between -1 and 1.
+func (e *Effect) Render() []float32 {
+	n := int(e.Duration() * sampleRate)
+	out := make([]float32, n)
+	lfsr := uint16(0xACE1)
+	phase, overtone := 0.0, 0.0
+	for i := range out {
+		t := float64(i) / sampleRate
+
+		freq := math.Max(e.MinFrequency, e.Frequency+e.Slide*t+e.SlideAccel*t*t/2)
+		if len(e.Steps) > 0 {
+			freq *= e.Steps[min(i*len(e.Steps)/n, len(e.Steps)-1)]
+		}
+		if e.VibratoDepth > 0 {
+			freq *= 1 + e.VibratoDepth*math.Sin(2*math.Pi*e.VibratoSpeed*t)
+		}
+		phase += freq / sampleRate
+		phase -= math.Floor(phase)
+		overtone += freq * e.Overtone / sampleRate
+		overtone -= math.Floor(overtone)
+
+		bit := (lfsr ^ lfsr>>2 ^ lfsr>>3 ^ lfsr>>5) & 1
+		lfsr = lfsr>>1 | bit<<15
+		noise := float64(int16(lfsr)) / 32768
+
+		var tone float64
+		switch e.Wave {
+		case WaveSine:
+			tone = math.Sin(2 * math.Pi * phase)
+		case WaveSquare:
+			tone = -1
+			if phase < e.Duty {
+				tone = 1
+			}
+		case WaveTriangle:
+			tone = 1 - 4*math.Abs(phase-0.5)
+		case WaveSaw:
+			tone = 2*phase - 1
+		case WaveNoise:
+			tone = noise
+		}
+		if e.Overtone > 0 {
+			tone += e.OvertoneMix * math.Sin(2*math.Pi*overtone)
+		}
+		v := (1-e.Noise)*tone + e.Noise*noise
+		out[i] = float32(v * e.envelope(t) * e.Volume)
+	}
+	return out
+}
+
+// ParseEffect parses an effect file. Errors are positioned as "line: msg".
+func ParseEffect(name string, data []byte) (*Effect, error) {
+	e := &Effect{
+		Name:         name,
+		Wave:         WaveSine,
+		Frequency:    440,
+		MinFrequency: 20,
+		Duty:         0.5,
+		Sustain:      1,
+		Release:      0.1,
+		Curve:        1,
+		Volume:       0.25,
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	sawMagic := false
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !sawMagic {
+			var version int
+			if _, err := fmt.Sscanf(line, EffectFileMagic+" %d", &version); err != nil {
+				return nil, fmt.Errorf("%d: expected %q header", lineNo, EffectFileMagic)
+			}
+			if version != EffectFileVersion {
+				return nil, fmt.Errorf("%d: unsupported effect format version %d", lineNo, version)
+			}
+			sawMagic = true
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf(`%d: expected "key: value"`, lineNo)
+		}
+		if err := e.setField(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("%d: %w", lineNo, err)
+		}
+	}
+	if !sawMagic {
+		return nil, fmt.Errorf("missing %q header", EffectFileMagic)
+	}
+	if e.Duration() <= 0 {
+		return nil, fmt.Errorf("attack, decay, hold and release add up to no time at all")
+	}
+	if e.Duration() > maxEffectDuration {
+		return nil, fmt.Errorf("attack, decay, hold and release add up to more than %d seconds", maxEffectDuration)
+	}
+	return e, nil
+}
+
+func (e *Effect) setField(key, value string) error {
+	if key == "wave" {
+		w, ok := waveNames[value]
+		if !ok {
+			return fmt.Errorf("wave: unknown wave %q", value)
+		}
+		e.Wave = w
+		return nil
+	}
+
+	nums, err := parseFloats(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	want := 1
+	switch key {
+	case "steps":
+		e.Steps, want = nums, len(nums)
+	case "vibrato", "overtone":
+		want = 2
+	}
+	if len(nums) != want || want == 0 {
+		return fmt.Errorf("%s: expected %d values but got %d", key, max(want, 1), len(nums))
+	}
+	for _, n := range nums {
+		if n < 0 && key != "slide" && key != "slide-accel" {
+			return fmt.Errorf("%s: must not be negative", key)
+		}
+	}
+
+	v := nums[0]
+	switch key {
+	case "steps":
+	case "frequency":
+		e.Frequency = v
+	case "slide":
+		e.Slide = v
+	case "slide-accel":
+		e.SlideAccel = v
+	case "min-frequency":
+		e.MinFrequency = v
+	case "duty":
+		e.Duty, err = v, atMostOne(v)
+	case "vibrato":
+		e.VibratoDepth, e.VibratoSpeed, err = v, nums[1], atMostOne(v)
+	case "overtone":
+		e.Overtone, e.OvertoneMix, err = v, nums[1], atMostOne(nums[1])
+	case "noise":
+		e.Noise, err = v, atMostOne(v)
+	case "attack":
+		e.Attack = v
+	case "decay":
+		e.Decay = v
+	case "sustain":
+		e.Sustain, err = v, atMostOne(v)
+	case "hold":
+		e.Hold = v
+	case "release":
+		e.Release = v
+	case "curve":
+		e.Curve = v
+		if v == 0 {
+			err = fmt.Errorf("must be above 0")
+		}
+	case "volume":
+		e.Volume, err = v, atMostOne(v)
+	default:
+		return fmt.Errorf("unknown field %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// atMostOne checks a share or level, which runs from 0 to 1.
+func atMostOne(v float64) error {
+	if v > 1 {
+		return fmt.Errorf("must be 0-1")
+	}
+	return nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var nums []float64
+	for _, f := range strings.Fields(s) {
+		n, err := strconv.ParseFloat(f, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("bad number %q", f)
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+// LoadEffectFile reads and parses a single effect file, named after it.
+func LoadEffectFile(path string) (*Effect, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := ParseEffect(strings.TrimSuffix(filepath.Base(path), EffectFileExt), data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return e, nil
+}
+
+//go:embed sfx/*.sfx
+var builtinFS embed.FS
+
+// Effects holds the definition of every sound.
+type Effects [soundCount]*Effect
+
+// DefaultEffects returns the built-in definitions, embedded from the sfx
+// directory.
+func DefaultEffects() Effects {
+	var fx Effects
+	for s, name := range soundNames {
+		p := "sfx/" + name + EffectFileExt
+		data, err := fs.ReadFile(builtinFS, p)
+		if err != nil {
+			panic(err)
+		}
+		e, err := ParseEffect(name, data)
+		if err != nil {
+			panic(fmt.Sprintf("audio: built-in %s: %v", p, err))
+		}
+		fx[s] = e
+	}
+	return fx
+}
+
+// LoadDir replaces the definitions of the sounds that have a file in dir.
+func (fx *Effects) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+EffectFileExt))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("%s: no %s files", dir, EffectFileExt)
+	}
+	for _, p := range paths {
+		e, err := LoadEffectFile(p)
+		if err != nil {
+			return err
+		}
+		s, ok := SoundNamed(e.Name)
+		if !ok {
+			return fmt.Errorf("%s: no sound is called %q", p, e.Name)
+		}
+		fx[s] = e
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEffect(t *testing.T) {
+	e, err := ParseEffect("test", []byte(`# leading comment
+tankstrike-sfx 1
+
+wave: square
+frequency: 200
+slide: -300
+steps: 1 1.5 2
+duty: 0.25
+vibrato: 0.1 8
+overtone: 3 0.5
+noise: 1
+sustain: 0
+release: 0.5
+curve: 0.5
+volume: 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Effect{
+		Name: "test", Wave: WaveSquare, Frequency: 200, Slide: -300, MinFrequency: 20,
+		Steps: []float64{1, 1.5, 2}, Duty: 0.25, VibratoDepth: 0.1, VibratoSpeed: 8, Overtone: 3, OvertoneMix: 0.5,
+		Noise: 1, Sustain: 0, Release: 0.5, Curve: 0.5, Volume: 1,
+	}
+	if !reflect.DeepEqual(e, want) {
+		t.Errorf("parsed\n%+v\nwant\n%+v", e, want)
+	}
+}
+
+func TestParseEffectErrors(t *testing.T) {
+	const header = "tankstrike-sfx 1\n"
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", `missing "tankstrike-sfx" header`},
+		{"no header", "wave: sine\n", `1: expected "tankstrike-sfx" header`},
+		{"other magic", "tankstrike-ai 1\n", `1: expected "tankstrike-sfx" header`},
+		{"newer version", "\ntankstrike-sfx 2\n", "2: unsupported effect format version 2"},
+		{"not key: value", header + "volume 0.5\n", `2: expected "key: value"`},
+		{"unknown field", header + "pitch: 3\n", `2: unknown field "pitch"`},
+		{"unknown wave", header + "wave: pulse\n", `2: wave: unknown wave "pulse"`},
+		{"bad number", header + "frequency: high\n", `2: frequency: bad number "high"`},
+		{"NaN", header + "frequency: NaN\n", `2: frequency: bad number "NaN"`},
+		{"infinity", header + "slide: -Inf\n", `2: slide: bad number "-Inf"`},
+		{"no value", header + "volume:\n", "2: volume: expected 1 values but got 0"},
+		{"two values", header + "volume: 0.1 0.2\n", "2: volume: expected 1 values but got 2"},
+		{"one vibrato value", header + "vibrato: 0.1\n", "2: vibrato: expected 2 values but got 1"},
+		{"no steps", header + "steps:\n", "2: steps: expected 1 values but got 0"},
+		{"negative", header + "attack: -0.1\n", "2: attack: must not be negative"},
+		{"negative step", header + "steps: 1 -2\n", "2: steps: must not be negative"},
+		{"loud", header + "volume: 1.5\n", "2: volume: must be 0-1"},
+		{"sustain above 1", header + "sustain: 2\n", "2: sustain: must be 0-1"},
+		{"duty above 1", header + "duty: 1.1\n", "2: duty: must be 0-1"},
+		{"noise above 1", header + "noise: 1.01\n", "2: noise: must be 0-1"},
+		{"deep vibrato", header + "vibrato: 2 5\n", "2: vibrato: must be 0-1"},
+		{"loud overtone", header + "overtone: 2 1.5\n", "2: overtone: must be 0-1"},
+		{"flat curve", header + "curve: 0\n", "2: curve: must be above 0"},
+		{"silent", header + "release: 0\n", "no time at all"},
+		{"too long", header + "attack: 4\nhold: 4\nrelease: 2.5\n", "more than 10 seconds"},
+	}
+	for _, tc := range tests {
+		_, err := ParseEffect("test", []byte(tc.data))
+		if err == nil {
+			t.Errorf("%s: parsed", tc.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestParseEffectLongest(t *testing.T) {
+	if _, err := ParseEffect("test", []byte("tankstrike-sfx 1\nhold: 9.9\nrelease: 0.1\n")); err != nil {
+		t.Errorf("effect of exactly %d seconds: %v", maxEffectDuration, err)
+	}
+}
+
+func TestDefaultEffects(t *testing.T) {
+	for s, e := range DefaultEffects() {
+		if e == nil || len(e.Render()) == 0 {
+			t.Errorf("%v: no effect", Sound(s))
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package audio
+
+import "strings"
+
+// Track names one of the game's tunes.
+type Track int
+
+const (
+	MusicNone Track = iota
+	MusicTitle
+	MusicLevel
+	MusicBoss // the last enemies of a level
+)
+
+// songs holds every track's tune, built once at startup.
+var songs = map[Track]*Song{
+	MusicTitle: titleTheme(),
+	MusicLevel: levelTheme(),
+	MusicBoss:  bossTheme(),
+}
+
+// rowsPerBar is the bar length of every song: four beats of four rows.
+const rowsPerBar = 16
+
+// The channel layout shared by every song.
+var (
+	lead    = Voice{Wave: WaveSquare, Duty: 0.5, Volume: 0.3, Decay: 1.5, Sustain: 0.5}
+	harmony = Voice{Wave: WaveSquare, Duty: 0.25, Volume: 0.15, Decay: 6, Sustain: 0.2}
+	bass    = Voice{Wave: WaveTriangle, Volume: 0.35, Sustain: 1}
+	drums   = Voice{Wave: WaveNoise, Volume: 0.2, Decay: 12}
+)
+
+func titleTheme() *Song {
+	verse := []string{"C", "G", "Am", "F"}
+	bridge := []string{"F", "G", "Em", "Am"}
+	return &Song{
+		Tempo:       112,
+		RowsPerBeat: 4,
+		Voices:      []Voice{lead, harmony, bass, drums},
+		Patterns: []Pattern{
+			{
+				Notes(`E5 . . . G5 . E5 . C5 . . . D5 . E5 .
+				       D5 . . . B4 . . . G4 . . . B4 . D5 .
+				       C5 . . . E5 . . . A5 . . . G5 . E5 .
+				       F5 . . . E5 . D5 . C5 . . . - . . .`),
+				arpeggio(verse, 4, 2),
+				bassLine(verse, "r . . . f . . . o . . . f . . ."),
+				beat(len(verse), "k . . . h . . . s . . . h . . ."),
+			},
+			{
+				Notes(`A5 . . . G5 . F5 . E5 . . . C5 . . .
+				       D5 . . . D5 . E5 . G5 . . . . . . .
+				       E5 . . . D5 . C5 . B4 . . . G4 . . .
+				       A4 . . . . . . . - . . . . . . .`),
+				arpeggio(bridge, 4, 2),
+				bassLine(bridge, "r . . . f . . . o . . . f . . ."),
+				beat(len(bridge), "k . . . h . . . s . . . h . h ."),
+			},
+		},
+		Order: []int{0, 1},
+		Loop:  0,
+	}
+}
+
+func levelTheme() *Song {
+	march := []string{"Am", "Am", "F", "G"}
+	climb := []string{"Am", "Dm", "E", "Am"}
+	return &Song{
+		Tempo:       144,
+		RowsPerBeat: 4,
+		Voices:      []Voice{lead, harmony, bass, drums},
+		Patterns: []Pattern{
+			{
+				// Intro: rhythm section only.
+				rest(len(march)),
+				rest(len(march)),
+				bassLine(march, "r . - r r . - r o . - r f . - r"),
+				beat(len(march), "k . h . s . h . k . k . s . h h"),
+			},
+			{
+				Notes(`A4 . . A4 C5 . A4 . E5 . . . D5 . C5 .
+				       B4 . . B4 C5 . B4 . A4 . . . E4 . . .
+				       F4 . A4 . C5 . F5 . E5 . . . C5 . A4 .
+				       G4 . B4 . D5 . G5 . F5 . E5 . D5 . B4 .`),
+				arpeggio(march, 4, 1),
+				bassLine(march, "r . - r r . - r o . - r f . - r"),
+				beat(len(march), "k . h . s . h . k . k . s . h h"),
+			},
+			{
+				Notes(`A5 . . . G5 . E5 . C5 . . . E5 . A5 .
+				       F5 . . . E5 . D5 . A4 . . . D5 . F5 .
+				       E5 . . . D5 . B4 . G#4 . . . B4 . E5 .
+				       A4 . . . . . . . - . . . A4 . - .`),
+				arpeggio(climb, 4, 1),
+				bassLine(climb, "r . - r r . - r o . - r f . - r"),
+				beat(len(climb), "k . h . s . h . k . k . s . s s"),
+			},
+		},
+		Order: []int{0, 1, 2, 1, 2},
+		Loop:  1,
+	}
+}
+
+func bossTheme() *Song {
+	dread := []string{"Em", "C", "Am", "B"}
+	return &Song{
+		Tempo:       168,
+		RowsPerBeat: 4,
+		Voices: []Voice{
+			{Wave: WaveSquare, Duty: 0.125, Volume: 0.3, Decay: 2, Sustain: 0.6},
+			harmony, bass, drums,
+		},
+		Patterns: []Pattern{
+			{
+				Notes(`E5 . G5 . F#5 . G5 . E5 . B4 . C5 . B4 .
+				       E5 . G5 . F#5 . G5 . C6 . B5 . G5 . E5 .
+				       E5 . G5 . F#5 . G5 . A5 . G5 . F#5 . E5 .
+				       D#5 . . . F#5 . . . B5 . . . A5 . F#5 .`),
+				arpeggio(dread, 5, 1),
+				bassLine(dread, "r r o r r r o r r r o r f f o f"),
+				beat(len(dread), "k . h k s . h . k . h k s . s s"),
+			},
+			{
+				Notes(`B5 . . . A5 . G5 . F#5 . . . E5 . D#5 .
+				       E5 . . . G5 . . . C6 . . . B5 . . .
+				       A5 . . . G5 . F#5 . E5 . . . C5 . E5 .
+				       D#5 . F#5 . B5 . F#5 . D#5 . B4 . D#5 . F#5 .`),
+				arpeggio(dread, 5, 1),
+				bassLine(dread, "r r o r r r o r r r o r f f o f"),
+				beat(len(dread), "k k h k s k h k k k h k s s s s"),
+			},
+		},
+		Order: []int{0, 1},
+		Loop:  0,
+	}
+}
+
+// chordTones returns the root, third and fifth of a chord named like
+// "C", "F#" or "Am", with the root in the given octave.
+func chordTones(name string, octave int) [3]Note {
+	minor := strings.HasSuffix(name, "m")
+	root := Notes(strings.TrimSuffix(name, "m") + string(rune('0'+octave)))[0]
+	third := Note(4)
+	if minor {
+		third = 3
+	}
+	return [3]Note{root, root + third, root + 7}
+}
+
+// bassLine plays a bar of style over each chord. In style "r" strikes the
+// root, "o" the root an octave up, "f" the fifth, and "." and "-" hold and
+// rest.
+func bassLine(chords []string, style string) []Note {
+	var notes []Note
+	for _, c := range chords {
+		tones := chordTones(c, 2)
+		for _, tok := range strings.Fields(style) {
+			switch tok {
+			case "r":
+				notes = append(notes, tones[0])
+			case "o":
+				notes = append(notes, tones[0]+12)
+			case "f":
+				notes = append(notes, tones[2])
+			default:
+				notes = append(notes, Notes(tok)...)
+			}
+		}
+	}
+	return notes
+}
+
+// arpeggio runs up and down each chord's tones, a note every step rows.
+func arpeggio(chords []string, octave, step int) []Note {
+	order := []int{0, 1, 2, 1}
+	var notes []Note
+	for _, c := range chords {
+		tones := chordTones(c, octave)
+		for row := range rowsPerBar {
+			if row%step != 0 {
+				notes = append(notes, Hold)
+				continue
+			}
+			notes = append(notes, tones[order[row/step%len(order)]])
+		}
+	}
+	return notes
+}
+
+// Drum sounds, as noise clock rates.
+var drumKit = map[string]Note{
+	"k": Notes("C2")[0], // kick
+	"s": Notes("G4")[0], // snare
+	"h": Notes("C7")[0], // hi-hat
+}
+
+// beat repeats a one-bar drum pattern for bars bars. In pattern "k", "s"
+// and "h" are kick, snare and hi-hat, and "." holds.
+func beat(bars int, pattern string) []Note {
+	var notes []Note
+	for range bars {
+		for _, tok := range strings.Fields(pattern) {
+			if n, ok := drumKit[tok]; ok {
+				notes = append(notes, n)
+			} else {
+				notes = append(notes, Notes(tok)...)
+			}
+		}
+	}
+	return notes
+}
+
+// rest returns bars bars of silence.
+func rest(bars int) []Note {
+	notes := make([]Note, bars*rowsPerBar)
+	notes[0] = Rest
+	return notes
+}
@@ -0,0 +1,57 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// WriteWAV writes 16-bit PCM samples, interleaved if there is more than
+// one channel, as a WAV file.
+func WriteWAV(w io.Writer, pcm []byte, channels int) error {
+	header := struct {
+		RIFF          [4]byte
+		Size          uint32
+		WAVE          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		Size:          uint32(36 + len(pcm)),
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		Format:        1, // PCM
+		Channels:      uint16(channels),
+		SampleRate:    sampleRate,
+		ByteRate:      uint32(sampleRate * channels * 2),
+		BlockAlign:    uint16(channels * 2),
+		BitsPerSample: 16,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      uint32(len(pcm)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	_, err := w.Write(pcm)
+	return err
+}
+
+// PCM converts samples between -1 and 1 to 16-bit mono PCM, clipping
+// anything outside.
+func PCM(samples []float32) []byte {
+	pcm := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		s = max(-1, min(s, 1))
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(s*math.MaxInt16)))
+	}
+	return pcm
+}
@@ -49,13 +49,26 @@ const (
 	RespawnDelay = 2.0 // seconds before player respawns
 	StartLives   = 3
 
+	MaxPlayers      = 4 // players in one match, including networked ones
+	MaxLocalPlayers = 2 // players sharing one keyboard
+
 	PowerUpDuration = 15.0 // seconds for timed power-ups (helmet, clock, shovel)
+	ShieldWarning   = 1.0  // seconds of shield left when players are warned it is ending
 
 	IceSlideMultiplier = 1.6
 	IceFriction        = 0.92
 
 	AIDirectionMinTime = 0.5
 	AIDirectionMaxTime = 2.5
+
+	AIAimRate = 4.0 // aimed shots per second of a perfectly accurate enemy
+)
+
+// Simulation timing
+const (
+	TickRate     = 120 // simulation ticks per second
+	TickDuration = 1.0 / TickRate
+	MaxFrameTime = 0.25 // longest frame the tick accumulator absorbs, in seconds
 )
 
 // Scoring
@@ -70,3 +83,7 @@ const (
 const (
 	MaxParticles = 512
 )
+
+// Version identifies the build. It is a variable so release builds can
+// stamp it with -ldflags "-X github.com/AchrafSoltani/TankStrike/config.Version=...".
+var Version = "1.0.0"
@@ -0,0 +1,98 @@
+package config
+
+import "strings"
+
+// Difficulty selects one of the balance presets.
+type Difficulty int
+
+const (
+	DifficultyEasy Difficulty = iota
+	DifficultyNormal
+	DifficultyHard
+	DifficultyNightmare
+
+	DifficultyCount = iota
+)
+
+// Preset is the balance a match plays with. Normal matches the constants
+// above; the others scale them.
+type Preset struct {
+	Name             string
+	EnemySpeed       float64 // multiplier on every enemy type's speed
+	ShootChance      float64 // multiplier on every enemy type's ShootChance
+	Accuracy         float64 // 0-1, how reliably enemies aim
+	SpawnInterval    float64 // seconds between enemy spawns
+	MaxActiveEnemies int
+	MixShift         int // random rosters are drawn as if the stage were this many later
+	StartLives       int
+	PowerUpEvery     int // every Nth enemy carries a power-up
+}
+
+var presets = [DifficultyCount]Preset{
+	DifficultyEasy: {
+		Name:             "EASY",
+		EnemySpeed:       0.85,
+		ShootChance:      0.7,
+		Accuracy:         0.5,
+		SpawnInterval:    4.0,
+		MaxActiveEnemies: 3,
+		MixShift:         -3,
+		StartLives:       5,
+		PowerUpEvery:     3,
+	},
+	DifficultyNormal: {
+		Name:             "NORMAL",
+		EnemySpeed:       1,
+		ShootChance:      1,
+		Accuracy:         0.75,
+		SpawnInterval:    SpawnInterval,
+		MaxActiveEnemies: MaxActiveEnemies,
+		StartLives:       StartLives,
+		PowerUpEvery:     4,
+	},
+	DifficultyHard: {
+		Name:             "HARD",
+		EnemySpeed:       1.15,
+		ShootChance:      1.3,
+		Accuracy:         0.9,
+		SpawnInterval:    2.5,
+		MaxActiveEnemies: 5,
+		MixShift:         3,
+		StartLives:       3,
+		PowerUpEvery:     5,
+	},
+	DifficultyNightmare: {
+		Name:             "NIGHTMARE",
+		EnemySpeed:       1.3,
+		ShootChance:      1.6,
+		Accuracy:         1,
+		SpawnInterval:    2.0,
+		MaxActiveEnemies: 6,
+		MixShift:         6,
+		StartLives:       2,
+		PowerUpEvery:     6,
+	},
+}
+
+// Preset returns the balance of difficulty d. Out of range values get
+// Normal.
+func (d Difficulty) Preset() Preset {
+	if d < 0 || d >= DifficultyCount {
+		d = DifficultyNormal
+	}
+	return presets[d]
+}
+
+func (d Difficulty) String() string {
+	return d.Preset().Name
+}
+
+// ParseDifficulty returns the difficulty named name, in any case.
+func ParseDifficulty(name string) (Difficulty, bool) {
+	for d := range Difficulty(DifficultyCount) {
+		if strings.EqualFold(presets[d].Name, name) {
+			return d, true
+		}
+	}
+	return DifficultyNormal, false
+}
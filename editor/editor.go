@@ -0,0 +1,350 @@
+// Package editor implements the construction mode: painting tiles onto a
+// level grid, placing the eagle and spawn points, and saving the result as
+// a level file. It has no window dependency; the game package maps keys
+// onto the methods here and the render package draws the Editor.
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// Brush is the area painted by a single stroke.
+type Brush int
+
+const (
+	BrushBlock   Brush = iota // a full 2x2 block
+	BrushQuarter              // a single sub-block
+)
+
+// Size returns the brush width and height in sub-blocks.
+func (b Brush) Size() int {
+	if b == BrushQuarter {
+		return 1
+	}
+	return 2
+}
+
+// Palette lists the tiles that can be painted, in selection order. The
+// eagle is not painted directly but moved with PlaceEagle.
+var Palette = []world.TileType{
+	world.TileEmpty,
+	world.TileBrick,
+	world.TileSteel,
+	world.TileWater,
+	world.TileIce,
+	world.TileForest,
+}
+
+// maxUndo bounds the undo history.
+const maxUndo = 200
+
+// snapshot is everything an edit can change.
+type snapshot struct {
+	tiles        [config.GridHeight][config.GridWidth]world.TileType
+	enemySpawns  [][2]int
+	playerSpawns [][2]int
+	eagle        [2]int
+}
+
+// Editor holds a level under construction.
+type Editor struct {
+	Level    *world.Level // metadata; the layout is rebuilt from Grid by Build
+	Grid     *world.Grid
+	CursorX  int // sub-block coordinates of the cursor's top-left corner
+	CursorY  int
+	Brush    Brush
+	Selected int    // index into Palette
+	Spawn    int    // player whose spawn PlacePlayerSpawn moves
+	Path     string // file the level is saved to
+	Status   string // message shown to the user
+	Dirty    bool   // unsaved changes
+
+	undo []snapshot
+	redo []snapshot
+}
+
+// New opens the level at path for editing, or starts a blank level if the
+// file does not exist yet.
+func New(path string) (*Editor, error) {
+	e := &Editor{Path: path, Grid: world.NewGrid(), Selected: 1}
+	l, err := world.LoadLevelFile(path)
+	switch {
+	case err == nil:
+		e.Level = l
+		if err := l.Load(e.Grid); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		e.Status = "LOADED " + filepath.Base(path)
+	case errors.Is(err, fs.ErrNotExist):
+		e.Level = blankLevel(e.Grid)
+		e.Status = "NEW LEVEL " + filepath.Base(path)
+	default:
+		return nil, err
+	}
+	// Place every co-op player explicitly so all spawns are shown and saved.
+	spawns := make([][2]int, config.MaxPlayers)
+	for i := range spawns {
+		spawns[i] = e.Level.PlayerSpawn(i)
+	}
+	e.Level.PlayerSpawns = spawns
+	e.CursorX, e.CursorY = spawns[0][0], spawns[0][1]
+	return e, nil
+}
+
+// blankLevel fills g with an empty field around a brick-walled eagle.
+func blankLevel(g *world.Grid) *world.Level {
+	g.Clear()
+	eagle := [2]int{config.GridWidth/2 - 1, config.GridHeight - 2}
+	for y := eagle[1] - 1; y < config.GridHeight; y++ {
+		for x := eagle[0] - 1; x <= eagle[0]+2; x++ {
+			g.Set(x, y, world.TileBrick)
+		}
+	}
+	for dy := 0; dy < 2; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			g.Set(eagle[0]+dx, eagle[1]+dy, world.TileEagle)
+		}
+	}
+	return &world.Level{
+		Name:         "Custom",
+		EnemySpawns:  slices.Clone(world.DefaultEnemySpawns),
+		PlayerSpawns: slices.Clone(world.DefaultPlayerSpawns),
+		Eagle:        eagle,
+	}
+}
+
+// Tile returns the tile currently selected for painting.
+func (e *Editor) Tile() world.TileType {
+	return Palette[e.Selected]
+}
+
+// Move steps the cursor by one brush width in the given direction.
+func (e *Editor) Move(dx, dy int) {
+	n := e.Brush.Size()
+	e.CursorX = clamp(e.CursorX+dx*n, 0, config.GridWidth-n)
+	e.CursorY = clamp(e.CursorY+dy*n, 0, config.GridHeight-n)
+}
+
+// Select picks the palette entry at index i.
+func (e *Editor) Select(i int) {
+	if i >= 0 && i < len(Palette) {
+		e.Selected = i
+	}
+}
+
+// NextTile cycles through the palette.
+func (e *Editor) NextTile() {
+	e.Selected = (e.Selected + 1) % len(Palette)
+}
+
+// ToggleBrush switches between the block and quarter brushes. Switching to
+// the block brush snaps the cursor onto the block grid.
+func (e *Editor) ToggleBrush() {
+	if e.Brush == BrushBlock {
+		e.Brush = BrushQuarter
+		return
+	}
+	e.Brush = BrushBlock
+	e.CursorX &^= 1
+	e.CursorY &^= 1
+}
+
+// Paint fills the area under the cursor with the selected tile. Eagle
+// tiles are left alone; use PlaceEagle to move the eagle.
+func (e *Editor) Paint() {
+	t := e.Tile()
+	n := e.Brush.Size()
+	changed := false
+	for y := e.CursorY; y < e.CursorY+n; y++ {
+		for x := e.CursorX; x < e.CursorX+n; x++ {
+			if cur := e.Grid.Get(x, y); cur != t && cur != world.TileEagle {
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return
+	}
+	e.push()
+	for y := e.CursorY; y < e.CursorY+n; y++ {
+		for x := e.CursorX; x < e.CursorX+n; x++ {
+			if e.Grid.Get(x, y) != world.TileEagle {
+				e.Grid.Set(x, y, t)
+			}
+		}
+	}
+}
+
+// PlaceEagle moves the eagle to the block under the cursor.
+func (e *Editor) PlaceEagle() {
+	pos := e.blockCursor()
+	if pos == e.Level.Eagle {
+		return
+	}
+	e.push()
+	for y := 0; y < config.GridHeight; y++ {
+		for x := 0; x < config.GridWidth; x++ {
+			if e.Grid.Get(x, y) == world.TileEagle {
+				e.Grid.Set(x, y, world.TileEmpty)
+			}
+		}
+	}
+	for dy := 0; dy < 2; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			e.Grid.Set(pos[0]+dx, pos[1]+dy, world.TileEagle)
+		}
+	}
+	e.Level.Eagle = pos
+	e.Status = fmt.Sprintf("EAGLE AT %d,%d", pos[0], pos[1])
+}
+
+// NextSpawn selects the next player's spawn for PlacePlayerSpawn.
+func (e *Editor) NextSpawn() {
+	e.Spawn = (e.Spawn + 1) % len(e.Level.PlayerSpawns)
+	e.Status = fmt.Sprintf("PLACING %dP SPAWN", e.Spawn+1)
+}
+
+// PlacePlayerSpawn moves the selected player's spawn to the cursor.
+func (e *Editor) PlacePlayerSpawn() {
+	i := e.Spawn
+	pos := e.tankCursor()
+	if i >= len(e.Level.PlayerSpawns) || pos == e.Level.PlayerSpawns[i] {
+		return
+	}
+	e.push()
+	e.Level.PlayerSpawns = slices.Clone(e.Level.PlayerSpawns)
+	e.Level.PlayerSpawns[i] = pos
+	e.Status = fmt.Sprintf("%dP SPAWN AT %d,%d", i+1, pos[0], pos[1])
+}
+
+// ToggleEnemySpawn adds an enemy spawn at the cursor, or removes the one
+// already there. The last enemy spawn cannot be removed.
+func (e *Editor) ToggleEnemySpawn() {
+	pos := e.tankCursor()
+	i := slices.Index(e.Level.EnemySpawns, pos)
+	if i >= 0 && len(e.Level.EnemySpawns) == 1 {
+		e.Status = "A LEVEL NEEDS AN ENEMY SPAWN"
+		return
+	}
+	e.push()
+	if i >= 0 {
+		e.Level.EnemySpawns = slices.Delete(slices.Clone(e.Level.EnemySpawns), i, i+1)
+		e.Status = fmt.Sprintf("REMOVED ENEMY SPAWN %d,%d", pos[0], pos[1])
+		return
+	}
+	e.Level.EnemySpawns = append(slices.Clone(e.Level.EnemySpawns), pos)
+	e.Status = fmt.Sprintf("ADDED ENEMY SPAWN %d,%d", pos[0], pos[1])
+}
+
+// blockCursor returns the cursor snapped onto the 2x2 block grid.
+func (e *Editor) blockCursor() [2]int {
+	return [2]int{e.CursorX &^ 1, e.CursorY &^ 1}
+}
+
+// tankCursor returns the cursor clamped so a 2x2 tank fits on the grid.
+func (e *Editor) tankCursor() [2]int {
+	return [2]int{min(e.CursorX, config.GridWidth-2), min(e.CursorY, config.GridHeight-2)}
+}
+
+// Undo reverts the last edit.
+func (e *Editor) Undo() {
+	if len(e.undo) == 0 {
+		e.Status = "NOTHING TO UNDO"
+		return
+	}
+	e.redo = append(e.redo, e.snapshot())
+	e.restore(e.undo[len(e.undo)-1])
+	e.undo = e.undo[:len(e.undo)-1]
+	e.Dirty = true
+	e.Status = "UNDO"
+}
+
+// Redo reapplies the last undone edit.
+func (e *Editor) Redo() {
+	if len(e.redo) == 0 {
+		e.Status = "NOTHING TO REDO"
+		return
+	}
+	e.undo = append(e.undo, e.snapshot())
+	e.restore(e.redo[len(e.redo)-1])
+	e.redo = e.redo[:len(e.redo)-1]
+	e.Dirty = true
+	e.Status = "REDO"
+}
+
+// push records the current state before an edit.
+func (e *Editor) push() {
+	if len(e.undo) == maxUndo {
+		e.undo = slices.Delete(e.undo, 0, 1)
+	}
+	e.undo = append(e.undo, e.snapshot())
+	e.redo = e.redo[:0]
+	e.Dirty = true
+}
+
+func (e *Editor) snapshot() snapshot {
+	return snapshot{
+		tiles:        e.Grid.Tiles,
+		enemySpawns:  e.Level.EnemySpawns,
+		playerSpawns: e.Level.PlayerSpawns,
+		eagle:        e.Level.Eagle,
+	}
+}
+
+func (e *Editor) restore(s snapshot) {
+	e.Grid.Tiles = s.tiles
+	e.Level.EnemySpawns = s.enemySpawns
+	e.Level.PlayerSpawns = s.playerSpawns
+	e.Level.Eagle = s.eagle
+}
+
+// Build returns a copy of the level with its layout taken from the grid.
+func (e *Editor) Build() *world.Level {
+	l := *e.Level
+	l.EnemySpawns = slices.Clone(e.Level.EnemySpawns)
+	l.PlayerSpawns = slices.Clone(e.Level.PlayerSpawns)
+	l.Layout = world.FormatGrid(e.Grid)
+	l.LayoutLine = 1 // report problems by grid row
+	return &l
+}
+
+// Check validates the level and reports the first problem in Status.
+// It returns true if the level is playable.
+func (e *Editor) Check() bool {
+	errs := e.Build().Validate()
+	if len(errs) == 0 {
+		return true
+	}
+	msg := errs[0].Msg
+	if len(errs) > 1 {
+		msg += fmt.Sprintf(" (+%d MORE)", len(errs)-1)
+	}
+	e.Status = strings.ToUpper(msg)
+	return false
+}
+
+// Save writes the level to Path. Levels that fail validation are still
+// saved so work is not lost, but the first problem is reported.
+func (e *Editor) Save() error {
+	if err := e.Build().Save(e.Path); err != nil {
+		e.Status = "SAVE FAILED"
+		return err
+	}
+	e.Dirty = false
+	if e.Check() {
+		e.Status = "SAVED " + filepath.Base(e.Path)
+	}
+	return nil
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
@@ -5,10 +5,13 @@ import "github.com/AchrafSoltani/TankStrike/config"
 // Bullet represents a projectile.
 type Bullet struct {
 	X, Y       float64
+	PrevX      float64 // position at the start of the current tick
+	PrevY      float64
 	Dir        Direction
 	Speed      float64
 	Power      int  // 0=normal, 3=can destroy steel
 	IsPlayer   bool // true if fired by player
+	Owner      int  // index of the player who fired it, when IsPlayer
 	Active     bool
 	TrailX     [3]float64
 	TrailY     [3]float64
@@ -20,6 +23,8 @@ func NewBullet(x, y float64, dir Direction, speed float64, power int, isPlayer b
 	return &Bullet{
 		X:        x,
 		Y:        y,
+		PrevX:    x,
+		PrevY:    y,
 		Dir:      dir,
 		Speed:    speed,
 		Power:    power,
@@ -28,6 +33,17 @@ func NewBullet(x, y float64, dir Direction, speed float64, power int, isPlayer b
 	}
 }
 
+// StorePrevious records the current position as the start of a new tick.
+func (b *Bullet) StorePrevious() {
+	b.PrevX = b.X
+	b.PrevY = b.Y
+}
+
+// Interpolate returns the position alpha (0-1) of the way through the current tick.
+func (b *Bullet) Interpolate(alpha float64) (float64, float64) {
+	return b.PrevX + (b.X-b.PrevX)*alpha, b.PrevY + (b.Y-b.PrevY)*alpha
+}
+
 // Update moves the bullet and records trail positions.
 func (b *Bullet) Update(dt float64) {
 	if !b.Active {
@@ -10,6 +10,9 @@ const (
 	DirRight
 )
 
+// AllDirections lists every direction, in the order searches try them.
+var AllDirections = [4]Direction{DirUp, DirDown, DirLeft, DirRight}
+
 // DX returns the X component of the direction vector.
 func (d Direction) DX() float64 {
 	switch d {
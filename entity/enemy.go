@@ -16,13 +16,16 @@ const (
 type EnemyTank struct {
 	Tank
 	Type           EnemyType
-	DirTimer       float64 // time until next direction change
+	DirTimer       float64 // time until a patrolling tank turns
 	DirInterval    float64 // how often to change direction
 	ShootChance    float64 // probability of shooting per second
 	ScoreValue     int
 	HasPowerUp     bool // drops a power-up when destroyed
 	FlashTimer     float64
 	FlashForPowerUp bool
+	Blocked        bool    // failed to move on the last tick
+	Breaching      bool    // blocked by brick or the eagle, which it should shoot
+	DetourTimer    float64 // time left driving around an obstacle it cannot shoot
 }
 
 // NewEnemyTank creates a new enemy tank of the given type at the given position.
@@ -1,19 +1,19 @@
 package entity
 
-import (
-	"github.com/AchrafSoltani/TankStrike/config"
-	"github.com/AchrafSoltani/glow"
-)
+import "github.com/AchrafSoltani/TankStrike/config"
 
 // PlayerTank extends Tank with player-specific features.
 type PlayerTank struct {
 	Tank
+	Index         int // player number, 0 for 1P
 	Lives         int
 	Score         int
 	Stars         int // upgrade level (0-3)
 	ShieldTimer   float64
 	RespawnTimer  float64
 	Respawning    bool
+	SpawnX        float64 // pixel position the tank respawns at
+	SpawnY        float64
 
 	// Ice sliding
 	SlideVX float64
@@ -21,52 +21,58 @@ type PlayerTank struct {
 	OnIce   bool
 }
 
-// NewPlayerTank creates a new player tank at the default spawn position.
-func NewPlayerTank() *PlayerTank {
+// NewPlayerTank creates player index's tank at the default spawn position.
+func NewPlayerTank(index int) *PlayerTank {
 	// Player spawns at bottom centre-left (sub-block 8,24 → pixel 192, 576)
 	spawnX := float64(8 * config.SubBlock)
 	spawnY := float64(24 * config.SubBlock)
 	p := &PlayerTank{
-		Tank:  NewTank(spawnX, spawnY, config.PlayerSpeed, 1),
-		Lives: config.StartLives,
+		Tank:   NewTank(spawnX, spawnY, config.PlayerSpeed, 1),
+		Index:  index,
+		Lives:  config.StartLives,
+		SpawnX: spawnX,
+		SpawnY: spawnY,
 	}
 	p.Tank.BulletSpeed = config.PlayerBulletSpd
 	p.Tank.CooldownRate = 0.3
 	return p
 }
 
-// HandleInput reads key state and updates movement direction.
-func (p *PlayerTank) HandleInput(keys map[glow.Key]bool) {
+// SetSpawn sets the respawn point to the given sub-block position.
+func (p *PlayerTank) SetSpawn(gridX, gridY int) {
+	p.SpawnX = float64(gridX * config.SubBlock)
+	p.SpawnY = float64(gridY * config.SubBlock)
+}
+
+// HandleInput updates movement direction from the held direction buttons.
+// When several are held, up wins over down, down over left, left over right.
+func (p *PlayerTank) HandleInput(up, down, left, right bool) {
 	if !p.Alive || p.Respawning {
 		p.Moving = false
 		return
 	}
 
 	p.Moving = false
-	if keys[glow.KeyW] || keys[glow.KeyUp] {
+	if up {
 		p.Dir = DirUp
 		p.Moving = true
-	} else if keys[glow.KeyS] || keys[glow.KeyDown] {
+	} else if down {
 		p.Dir = DirDown
 		p.Moving = true
-	} else if keys[glow.KeyA] || keys[glow.KeyLeft] {
+	} else if left {
 		p.Dir = DirLeft
 		p.Moving = true
-	} else if keys[glow.KeyD] || keys[glow.KeyRight] {
+	} else if right {
 		p.Dir = DirRight
 		p.Moving = true
 	}
 }
 
-// WantsToShoot returns true if the player is pressing fire.
-func (p *PlayerTank) WantsToShoot(keys map[glow.Key]bool) bool {
-	return keys[glow.KeySpace]
-}
-
 // Respawn resets the player tank to the spawn point.
 func (p *PlayerTank) Respawn() {
-	p.X = float64(8 * config.SubBlock)
-	p.Y = float64(24 * config.SubBlock)
+	p.X = p.SpawnX
+	p.Y = p.SpawnY
+	p.StorePrevious()
 	p.Dir = DirUp
 	p.HP = 1
 	p.Alive = true
@@ -1,6 +1,6 @@
 package entity
 
-import "math/rand"
+import "math/rand/v2"
 
 // PowerUpType represents the type of power-up.
 type PowerUpType int
@@ -23,13 +23,13 @@ type PowerUp struct {
 }
 
 // NewPowerUp creates a new power-up at a random position within the play area.
-func NewPowerUp() *PowerUp {
+func NewPowerUp(rng *rand.Rand) *PowerUp {
 	types := []PowerUpType{PowerUpStar, PowerUpTank, PowerUpHelmet, PowerUpShovel, PowerUpBomb, PowerUpClock}
-	typ := types[rand.Intn(len(types))]
+	typ := types[rng.IntN(len(types))]
 
 	// Random position, snapped to sub-block grid, avoiding edges
-	x := float64(2+rand.Intn(22)) * 24
-	y := float64(2+rand.Intn(22)) * 24
+	x := float64(2+rng.IntN(22)) * 24
+	y := float64(2+rng.IntN(22)) * 24
 
 	return &PowerUp{
 		X:      x,
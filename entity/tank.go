@@ -10,6 +10,9 @@ type Tank struct {
 	Alive     bool
 	Moving    bool       // whether the tank is currently moving
 
+	// Position at the start of the current tick, for render interpolation
+	PrevX, PrevY float64
+
 	// Animation
 	TreadFrame int     // alternates for tread animation
 	TreadTimer float64 // time accumulator for tread animation
@@ -26,6 +29,8 @@ func NewTank(x, y float64, speed float64, hp int) Tank {
 	return Tank{
 		X:            x,
 		Y:            y,
+		PrevX:        x,
+		PrevY:        y,
 		Dir:          DirUp,
 		Speed:        speed,
 		HP:           hp,
@@ -51,6 +56,17 @@ func (t *Tank) Update(dt float64) {
 	}
 }
 
+// StorePrevious records the current position as the start of a new tick.
+func (t *Tank) StorePrevious() {
+	t.PrevX = t.X
+	t.PrevY = t.Y
+}
+
+// Interpolate returns the position alpha (0-1) of the way through the current tick.
+func (t *Tank) Interpolate(alpha float64) (float64, float64) {
+	return t.PrevX + (t.X-t.PrevX)*alpha, t.PrevY + (t.Y-t.PrevY)*alpha
+}
+
 // CanShoot returns whether the tank can fire.
 func (t *Tank) CanShoot() bool {
 	return t.Alive && t.ShootCooldown <= 0
@@ -59,12 +75,16 @@ func (t *Tank) CanShoot() bool {
 // Shoot puts the tank on cooldown. Returns the bullet spawn position.
 func (t *Tank) Shoot() (float64, float64) {
 	t.ShootCooldown = t.CooldownRate
+	return t.Muzzle(t.Dir)
+}
 
+// Muzzle returns where a bullet fired in direction d spawns.
+func (t *Tank) Muzzle(d Direction) (float64, float64) {
 	// Bullet spawns at the barrel tip
 	cx := t.X + 24 // centre of 48px tank
 	cy := t.Y + 24
-	bx := cx + t.Dir.DX()*28 - 2 // offset to barrel tip, centred on 4px bullet
-	by := cy + t.Dir.DY()*28 - 2
+	bx := cx + d.DX()*28 - 2 // offset to barrel tip, centred on 4px bullet
+	by := cy + d.DY()*28 - 2
 	return bx, by
 }
 
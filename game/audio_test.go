@@ -0,0 +1,71 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/AchrafSoltani/TankStrike/audio"
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/glow"
+)
+
+// played is one sound effect started, and the tick it started on.
+type played struct {
+	tick  uint64
+	sound audio.Sound
+	pan   float64
+}
+
+// newTestGame returns a game with no sound device and no save file,
+// recording every sound effect it starts.
+func newTestGame(t *testing.T) (*Game, *[]played) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	g := NewGame(1, audio.NullOutput{})
+	t.Cleanup(g.Close)
+	g.NoSave = true
+	var plays []played
+	g.Audio.OnPlay = func(s audio.Sound, gain, pan float64) {
+		plays = append(plays, played{g.Tick, s, pan})
+	}
+	return g, &plays
+}
+
+func TestMatchSounds(t *testing.T) {
+	g, plays := newTestGame(t)
+	g.StartGame(1)
+	if len(*plays) == 0 || (*plays)[0] != (played{0, audio.SoundLevelStart, 0}) {
+		t.Fatalf("starting a level played %v, want the level start sound on tick 0", *plays)
+	}
+
+	for i := 0; g.State != StatePlaying; i++ {
+		if i > 10*config.TickRate {
+			t.Fatalf("level intro still showing after %d ticks", i)
+		}
+		g.Update(config.TickDuration)
+	}
+	for _, p := range *plays {
+		if p.sound == audio.SoundShoot {
+			t.Fatalf("shot heard on tick %d before anyone fired", p.tick)
+		}
+	}
+
+	fired := g.Tick
+	g.KeyDown(glow.KeySpace)
+	g.Update(config.TickDuration)
+	g.KeyUp(glow.KeySpace)
+
+	var shots []played
+	for _, p := range *plays {
+		if p.sound == audio.SoundShoot {
+			shots = append(shots, p)
+		}
+	}
+	if len(shots) != 1 || shots[0].tick != fired {
+		t.Fatalf("firing on tick %d played shots %v, want one on that tick", fired, shots)
+	}
+	// The listener is on the player's tank, so their own shot is heard
+	// from about the centre.
+	if pan := shots[0].pan; pan < -0.05 || pan > 0.05 {
+		t.Errorf("player's own shot panned to %v, want about 0", pan)
+	}
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"image/gif"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/render"
+)
+
+const (
+	// captureFPS is how many frames a second a clip keeps: every third
+	// frame of the 60 FPS game loop.
+	captureFPS = 20
+	// captureScale is how much clip frames are shrunk, to keep the ring
+	// buffer and the GIFs small.
+	captureScale = 2
+)
+
+// EnableCapture starts keeping the last seconds of play, to be saved as an
+// animated GIF with F9 or when a game ends.
+func (g *Game) EnableCapture(seconds float64) {
+	g.Clip = render.NewClip(max(1, int(seconds*captureFPS)), captureScale, 100/captureFPS)
+	g.clipTarget = render.NewImageTarget(config.WindowWidth, config.WindowHeight)
+}
+
+// captureFrame adds the current frame to the clip once enough time has
+// passed since the last one.
+func (g *Game) captureFrame(dt float64) {
+	if g.Clip == nil {
+		return
+	}
+	g.clipTimer += dt
+	if g.clipTimer < 1.0/captureFPS {
+		return
+	}
+	g.clipTimer = math.Mod(g.clipTimer, 1.0/captureFPS)
+
+	g.clipTarget.Clear(render.ColorBlack)
+	g.draw(render.NewScaledCanvas(g.clipTarget, config.NewLayout(config.WindowWidth, config.WindowHeight)))
+	g.Clip.Add(g.clipTarget.Image())
+}
+
+// closeCapture stops keeping frames, if capture is enabled.
+func (g *Game) closeCapture() {
+	if g.Clip != nil {
+		g.Clip.Close()
+		g.Clip = nil
+	}
+}
+
+// saveClip writes the clip so far to a time-stamped GIF in ScreenshotDir.
+// Quantising the last frames and encoding happen off the game loop.
+func (g *Game) saveClip() {
+	if g.Clip == nil || g.Clip.Len() == 0 {
+		return
+	}
+	clip := g.Clip.GIF()
+	dir := ScreenshotDir()
+	path := filepath.Join(dir, time.Now().Format("tankstrike-20060102-150405.000.gif"))
+	go func() {
+		anim := <-clip
+		if err := writeGIF(dir, path, anim); err != nil {
+			log.Printf("clip: %v", err)
+			return
+		}
+		log.Printf("clip of %d frames saved to %s", len(anim.Image), path)
+	}()
+}
+
+func writeGIF(dir, path string, anim *gif.GIF) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
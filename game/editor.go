@@ -0,0 +1,115 @@
+package game
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/AchrafSoltani/TankStrike/editor"
+	"github.com/AchrafSoltani/TankStrike/render"
+	"github.com/AchrafSoltani/TankStrike/save"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/TankStrike/world"
+	"github.com/AchrafSoltani/glow"
+)
+
+// DefaultEditorPath is where the construction menu entry saves its level.
+func DefaultEditorPath() string {
+	return filepath.Join(save.Dir(), "levels", "custom"+world.LevelFileExt)
+}
+
+// editorKeys maps keys to construction-mode actions. Cursor movement,
+// painting, test play and quitting go through the usual buttons.
+var editorKeys = map[glow.Key]func(*editor.Editor){
+	glow.KeyTab: (*editor.Editor).NextTile,
+	glow.Key1:   func(e *editor.Editor) { e.Select(0) },
+	glow.Key2:   func(e *editor.Editor) { e.Select(1) },
+	glow.Key3:   func(e *editor.Editor) { e.Select(2) },
+	glow.Key4:   func(e *editor.Editor) { e.Select(3) },
+	glow.Key5:   func(e *editor.Editor) { e.Select(4) },
+	glow.Key6:   func(e *editor.Editor) { e.Select(5) },
+	glow.KeyB:   (*editor.Editor).ToggleBrush,
+	glow.KeyE:   (*editor.Editor).PlaceEagle,
+	glow.KeyP:   (*editor.Editor).PlacePlayerSpawn,
+	glow.KeyL:   (*editor.Editor).NextSpawn,
+	glow.KeyO:   (*editor.Editor).ToggleEnemySpawn,
+	glow.KeyZ:   (*editor.Editor).Undo,
+	glow.KeyY:   (*editor.Editor).Redo,
+	glow.KeyF2: func(e *editor.Editor) {
+		if err := e.Save(); err != nil {
+			log.Printf("editor: %v", err)
+		}
+	},
+}
+
+// OpenEditor enters construction mode on the level file at path, which is
+// created on first save if it does not exist.
+func (g *Game) OpenEditor(path string) error {
+	ed, err := editor.New(path)
+	if err != nil {
+		return err
+	}
+	g.Editor = ed
+	g.State = StateEditor
+	return nil
+}
+
+func (g *Game) updateEditor() {
+	ed := g.Editor
+	in := g.Inputs[0]
+	switch {
+	case in.IsJustPressed(system.ButtonUp):
+		ed.Move(0, -1)
+	case in.IsJustPressed(system.ButtonDown):
+		ed.Move(0, 1)
+	case in.IsJustPressed(system.ButtonLeft):
+		ed.Move(-1, 0)
+	case in.IsJustPressed(system.ButtonRight):
+		ed.Move(1, 0)
+	}
+	// Holding fire paints along the cursor's path.
+	if in.IsDown(system.ButtonFire) {
+		ed.Paint()
+	}
+
+	for key, action := range editorKeys {
+		if g.keyJustPressed(key) {
+			action(ed)
+		}
+	}
+
+	if in.IsJustPressed(system.ButtonConfirm) {
+		g.testPlay()
+	} else if in.IsJustPressed(system.ButtonPause) {
+		g.Editor = nil
+		g.State = StateMenu
+		g.refreshMenuOptions()
+	}
+}
+
+// testPlay starts a one-level match on the level being edited. The match
+// is neither recorded nor counted towards saved progress.
+func (g *Game) testPlay() {
+	if !g.Editor.Check() {
+		return
+	}
+	g.testing = true
+	g.campaign = g.Sim.Levels
+	g.Sim.Levels = []*world.Level{g.Editor.Build()}
+	g.Sim.Start(0, g.Players)
+	g.handleEvents()
+	g.syncState()
+}
+
+// endTestPlay returns from a test match to the editor.
+func (g *Game) endTestPlay() {
+	g.testing = false
+	g.Sim.Levels = g.campaign
+	g.campaign = nil
+	g.Particles.Reset()
+	g.State = StateEditor
+	g.Editor.Status = "TEST PLAY OVER"
+}
+
+func (g *Game) drawEditor(canvas *render.ScaledCanvas) {
+	render.DrawEditor(canvas, g.Renderer, g.Editor, g.Time)
+}
@@ -1,142 +1,178 @@
 package game
 
 import (
+	"log"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"strings"
+
 	"github.com/AchrafSoltani/TankStrike/audio"
 	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/editor"
 	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/netplay"
 	"github.com/AchrafSoltani/TankStrike/render"
+	"github.com/AchrafSoltani/TankStrike/replay"
 	"github.com/AchrafSoltani/TankStrike/save"
+	"github.com/AchrafSoltani/TankStrike/sim"
 	"github.com/AchrafSoltani/TankStrike/system"
 	"github.com/AchrafSoltani/TankStrike/world"
 	"github.com/AchrafSoltani/glow"
 )
 
-// Game is the top-level game orchestrator.
+// Game adapts the headless simulation to a glow window: it turns key
+// events into buttons, presents simulation events as sound and particles,
+// and draws the result.
 type Game struct {
 	State     GameState
-	Grid      *world.Grid
+	Sim       *sim.Sim
 	Renderer  *render.Renderer
 	HUD       *render.HUDRenderer
-	Input     *system.Input
-	Player    *entity.PlayerTank
-	Eagle     *entity.Eagle
-	Enemies   []*entity.EnemyTank
-	Bullets   []*entity.Bullet
-	PowerUps  []*entity.PowerUp
+	Inputs    []*system.Input // one per player; 1P's also drives menus
+	Keys      map[glow.Key]bool
+	prevKeys  map[glow.Key]bool // Keys as of the previous tick
 	Particles *render.ParticlePool
-	Spawner   *system.Spawner
 	Audio     *audio.Engine
 	Shake     *system.ScreenShake
 	SaveData  *save.SaveData
 	Layout    config.Layout
-	Level     int
 	Time      float64
 
-	// Power-up timers
-	ClockTimer  float64 // freeze enemies timer
-	ShovelTimer float64 // fortified eagle timer
+	// Fixed-timestep bookkeeping
+	Tick        uint64  // simulation ticks run since startup
+	Alpha       float64 // fraction of a tick elapsed since the last one, for interpolation
+	accumulator float64
 
-	// Level stats
-	KillsBasic  int
-	KillsFast   int
-	KillsPower  int
-	KillsArmour int
+	// Players is the number of players in the current or most recent match.
+	Players int
 
 	// Menu state
 	MenuSelection int
 	MenuOptions   []render.MenuOption
-
-	// Transition timers
-	GameOverTimer   float64
-	LevelComplTimer float64
-	LevelIntroTimer float64
-}
-
-// NewGame creates a new game instance.
-func NewGame() *Game {
+	Difficulty    config.Difficulty // preset new matches are played on
+	Adaptive      bool              // new matches run the adaptive director
+
+	// NoSave keeps matches from writing the save file, for headless tools.
+	NoSave bool
+
+	// ShowDirector overlays the director's state on the play field.
+	ShowDirector bool
+
+	// Clip keeps the last few seconds of frames, if capture is enabled.
+	Clip       *render.Clip
+	clipTarget *render.ImageTarget // frames are drawn here before going into Clip
+	clipTimer  float64             // seconds since the last frame captured
+
+	// Replays
+	RecordPath string           // where to save each match, if set
+	Recorder   *replay.Recorder // recording of the current match
+	Playback   *replay.Playback // replay driving the current match instead of the keyboard
+
+	// Construction mode
+	Editor   *editor.Editor
+	testing  bool           // the match in progress is a test play of Editor's level
+	campaign []*world.Level // levels to restore when a test play ends
+
+	// LAN play
+	NetPort        int               // UDP port to host on or look for hosts on
+	NetDelay       int               // input delay, in ticks, when hosting
+	Host           *netplay.Host     // lobby being hosted
+	Client         *netplay.Client   // lobby being looked for or joined
+	Net            *netplay.Lockstep // networked match in progress
+	Rollback       *netplay.Rollback // drives Net's match, if it uses rollback
+	LobbySelection int               // host picked in the join lobby
+	netInputs      []*system.Input   // every player's input, as agreed over the network
+}
+
+// cosmeticStream selects the PCG stream for particles, kept apart from the
+// simulation's gameplay stream so visual effects never perturb a match.
+const cosmeticStream = 0x6678 // "fx"
+
+// NewGame creates a new game instance whose matches are driven by seed,
+// playing its audio through out.
+func NewGame(seed uint64, out audio.Output) *Game {
 	sd := save.Load()
+	difficulty, _ := config.ParseDifficulty(sd.Difficulty)
 	g := &Game{
 		State:     StateMenu,
-		Grid:      world.NewGrid(),
+		Sim:       sim.NewSim(seed),
 		Renderer:  render.NewRenderer(),
 		HUD:       render.NewHUDRenderer(),
-		Input:     system.NewInput(),
-		Player:    entity.NewPlayerTank(),
-		Particles: render.NewParticlePool(),
-		Audio:     audio.NewEngine(),
+		Inputs:    newInputs(),
+		Players:   1,
+		NetPort:   netplay.DefaultPort,
+		NetDelay:  netplay.DefaultDelay,
+		Keys:      make(map[glow.Key]bool),
+		prevKeys:  make(map[glow.Key]bool),
+		Particles: render.NewParticlePool(rand.New(rand.NewPCG(seed, cosmeticStream))),
+		Audio:     audio.NewEngine(out),
 		Shake:     &system.ScreenShake{},
 		SaveData:  sd,
 		Layout:    config.NewLayout(config.WindowWidth, config.WindowHeight),
-		Level:     0,
 		MenuOptions: []render.MenuOption{
-			{Label: "NEW GAME"},
-			{Label: "CONTINUE", Disabled: sd.MaxLevel == 0},
+			{Label: "1 PLAYER"},
+			{Label: "2 PLAYERS"},
+			{Label: "CONTINUE"},
+			{Label: "HOST LAN GAME"},
+			{Label: "JOIN LAN GAME"},
+			{Label: "CONSTRUCTION"},
+			{Label: "DIFFICULTY"},
 		},
+		Difficulty: difficulty,
 	}
+	g.refreshMenuOptions()
 	return g
 }
 
-// StartGame begins a new game from level 0.
-func (g *Game) StartGame() {
-	g.Player = entity.NewPlayerTank()
-	g.Level = 0
-	g.startLevel(0)
+func newInputs() []*system.Input {
+	inputs := make([]*system.Input, config.MaxPlayers)
+	for i := range inputs {
+		inputs[i] = system.NewInput()
+	}
+	return inputs
 }
 
-// ContinueGame resumes from the saved level.
-func (g *Game) ContinueGame() {
-	g.Player = entity.NewPlayerTank()
-	level := g.SaveData.MaxLevel
-	if level < 0 {
-		level = 0
-	}
-	if level >= len(world.Levels) {
-		level = len(world.Levels) - 1
-	}
-	g.startLevel(level)
-}
-
-func (g *Game) startLevel(index int) {
-	if index >= 0 && index < len(world.Levels) {
-		g.Level = index
-		world.LoadLevel(g.Grid, world.Levels[index])
-		g.Bullets = g.Bullets[:0]
-		g.Enemies = g.Enemies[:0]
-		g.PowerUps = g.PowerUps[:0]
-		g.ClockTimer = 0
-		g.ShovelTimer = 0
-		g.KillsBasic = 0
-		g.KillsFast = 0
-		g.KillsPower = 0
-		g.KillsArmour = 0
-		g.Spawner = system.NewSpawner(index)
-		g.findEagle()
-		g.Player.Respawn()
-		g.State = StateLevelIntro
-		g.LevelIntroTimer = 2.0
-		g.Audio.PlayLevelStart()
-	}
+// StartGame begins a new game from level 0 for the given number of players.
+func (g *Game) StartGame(players int) {
+	g.StartLevel(0, players)
 }
 
-func (g *Game) findEagle() {
-	for y := 0; y < config.GridHeight; y++ {
-		for x := 0; x < config.GridWidth; x++ {
-			if g.Grid.Get(x, y) == world.TileEagle {
-				g.Eagle = entity.NewEagle(x, y)
-				return
-			}
-		}
-	}
+// StartLevel begins a new game from the given level, counted from 0.
+func (g *Game) StartLevel(level, players int) {
+	g.Players = players
+	g.Sim.Difficulty = g.Difficulty
+	g.Sim.Adaptive = g.Adaptive
+	g.Sim.Start(level, players)
+	g.beginRecording()
+	g.handleEvents()
+	g.syncState()
+}
+
+// ContinueGame resumes from the saved level with as many players as the
+// last match.
+func (g *Game) ContinueGame() {
+	g.Sim.Difficulty = g.Difficulty
+	g.Sim.Adaptive = g.Adaptive
+	g.Sim.Start(g.SaveData.For(g.Difficulty).MaxLevel, g.Players)
+	g.beginRecording()
+	g.handleEvents()
+	g.syncState()
 }
 
 // KeyDown handles key press events.
 func (g *Game) KeyDown(key glow.Key) {
-	g.Input.KeyDown(key)
+	g.Keys[key] = true
 }
 
 // KeyUp handles key release events.
 func (g *Game) KeyUp(key glow.Key) {
-	g.Input.KeyUp(key)
+	g.Keys[key] = false
+}
+
+// keyJustPressed reports whether key went down since the previous tick.
+func (g *Game) keyJustPressed(key glow.Key) bool {
+	return g.Keys[key] && !g.prevKeys[key]
 }
 
 // OnResize recalculates the layout for a new window size.
@@ -144,428 +180,402 @@ func (g *Game) OnResize(width, height int) {
 	g.Layout = config.NewLayout(width, height)
 }
 
-// Update advances game state by dt seconds.
+// Update advances game state by dt seconds of wall-clock time, running as
+// many fixed-length ticks as have accumulated.
 func (g *Game) Update(dt float64) {
+	if dt > config.MaxFrameTime {
+		dt = config.MaxFrameTime
+	}
+	g.accumulator += dt
+	for g.accumulator >= config.TickDuration {
+		g.tick(config.TickDuration)
+		g.accumulator -= config.TickDuration
+		g.Tick++
+	}
+	g.Alpha = g.accumulator / config.TickDuration
+	g.updateMusic()
+	g.updateTankLoops()
+	g.captureFrame(dt)
+}
+
+// updateMusic picks the track for the current state: the title theme in
+// menus, the level theme in play and the boss theme once a level's last
+// enemies are on the field.
+func (g *Game) updateMusic() {
+	track := audio.MusicNone
+	switch g.State {
+	case StateMenu, StateLobby:
+		track = audio.MusicTitle
+	case StatePlaying, StatePaused:
+		track = audio.MusicLevel
+		if g.Sim.Spawner != nil && g.Sim.Spawner.Done() {
+			track = audio.MusicBoss
+		}
+	}
+	g.Audio.PlayMusic(track)
+	g.Audio.PauseMusic(g.State == StatePaused)
+}
+
+// updateTankLoops keeps the engine and ice sounds of the local players'
+// tanks running while they are in play.
+func (g *Game) updateTankLoops() {
+	var idle, moving, sliding bool
+	if g.State == StatePlaying {
+		for _, p := range g.localPlayers() {
+			if !p.Alive {
+				continue
+			}
+			if p.Moving {
+				moving = true
+				sliding = sliding || p.OnIce
+			} else {
+				idle = true
+			}
+		}
+	}
+	g.Audio.SetTankLoops(idle && !moving, moving, sliding)
+}
+
+func (g *Game) tick(dt float64) {
 	g.Time += dt
 	g.Renderer.Time = g.Time
-	g.Input.Update()
+	defer maps.Copy(g.prevKeys, g.Keys)
+
+	held := g.heldButtons()
+	if g.Playback != nil && g.State != StateMenu {
+		recorded, ok := g.Playback.Next()
+		if !ok {
+			g.finishPlayback()
+		} else {
+			for i := range held {
+				var b system.Button
+				if i < len(recorded) {
+					b = recorded[i]
+				}
+				held[i] = b | held[i]&system.HostButtons
+			}
+		}
+	}
+	for i, in := range g.Inputs {
+		in.Update(held[i])
+	}
 
 	// Global audio controls (all states)
-	if g.Input.IsJustPressed(glow.KeyM) {
+	host := g.Inputs[0]
+	if host.IsJustPressed(system.ButtonMute) {
 		g.Audio.ToggleMute()
 	}
-	if g.Input.IsJustPressed(glow.KeyEqual) {
+	if host.IsJustPressed(system.ButtonVolumeUp) {
 		g.Audio.VolumeUp()
 	}
-	if g.Input.IsJustPressed(glow.KeyMinus) {
+	if host.IsJustPressed(system.ButtonVolumeDown) {
 		g.Audio.VolumeDown()
 	}
+	if host.IsJustPressed(system.ButtonMusicUp) {
+		g.Audio.MusicVolumeUp()
+	}
+	if host.IsJustPressed(system.ButtonMusicDown) {
+		g.Audio.MusicVolumeDown()
+	}
+	if g.keyJustPressed(glow.KeyF3) {
+		g.ShowDirector = !g.ShowDirector
+	}
+	if g.keyJustPressed(glow.KeyF12) {
+		g.saveScreenshot()
+	}
+	if g.keyJustPressed(glow.KeyF9) {
+		g.saveClip()
+	}
 
 	switch g.State {
 	case StateMenu:
-		if g.Input.IsJustPressed(glow.KeyUp) || g.Input.IsJustPressed(glow.KeyW) {
-			g.MenuSelection--
-			if g.MenuSelection < 0 {
-				g.MenuSelection = len(g.MenuOptions) - 1
-			}
-			// Skip disabled options
-			if g.MenuOptions[g.MenuSelection].Disabled {
-				g.MenuSelection--
-				if g.MenuSelection < 0 {
-					g.MenuSelection = len(g.MenuOptions) - 1
-				}
-			}
-			g.Audio.PlayMenuSelect()
-		}
-		if g.Input.IsJustPressed(glow.KeyDown) || g.Input.IsJustPressed(glow.KeyS) {
-			g.MenuSelection++
-			if g.MenuSelection >= len(g.MenuOptions) {
-				g.MenuSelection = 0
-			}
-			// Skip disabled options
-			if g.MenuOptions[g.MenuSelection].Disabled {
-				g.MenuSelection++
-				if g.MenuSelection >= len(g.MenuOptions) {
-					g.MenuSelection = 0
-				}
-			}
-			g.Audio.PlayMenuSelect()
-		}
-		if g.Input.IsJustPressed(glow.KeyEnter) || g.Input.IsJustPressed(glow.KeySpace) {
-			if !g.MenuOptions[g.MenuSelection].Disabled {
-				switch g.MenuSelection {
-				case 0: // New Game
-					g.StartGame()
-				case 1: // Continue
-					g.ContinueGame()
-				}
-			}
-		}
-	case StateLevelIntro:
-		g.LevelIntroTimer -= dt
-		if g.LevelIntroTimer <= 0 {
-			g.State = StatePlaying
-		}
-	case StatePlaying:
-		g.updatePlaying(dt)
-		if g.Input.IsJustPressed(glow.KeyEscape) {
-			g.State = StatePaused
-		}
-	case StatePaused:
-		if g.Input.IsJustPressed(glow.KeyEscape) || g.Input.IsJustPressed(glow.KeyEnter) {
-			g.State = StatePlaying
-		}
-	case StateGameOver:
-		g.GameOverTimer -= dt
-		g.Particles.Update(dt)
-		if g.GameOverTimer <= 0 {
-			if g.Input.IsJustPressed(glow.KeyEnter) || g.Input.IsJustPressed(glow.KeySpace) {
-				g.State = StateMenu
-				g.refreshMenuOptions()
-			}
-		}
-	case StateLevelComplete:
-		g.LevelComplTimer -= dt
-		if g.LevelComplTimer <= 0 {
-			if g.Input.IsJustPressed(glow.KeyEnter) || g.Input.IsJustPressed(glow.KeySpace) {
-				next := g.Level + 1
-				if next < len(world.Levels) {
-					g.startLevel(next)
-				} else {
-					g.State = StateMenu
-					g.refreshMenuOptions()
-				}
-			}
-		}
-	}
-}
-
-func (g *Game) updatePlaying(dt float64) {
-	g.Player.HandleInput(g.Input.Keys)
-	g.Player.UpdatePlayer(dt)
-
-	otherTanks := g.enemyBBoxes()
-	system.MovePlayerTank(g.Player, g.Grid, dt, otherTanks)
-
-	if g.Player.WantsToShoot(g.Input.Keys) && g.Player.CanShoot() {
-		if system.CountPlayerBullets(g.Bullets) < config.MaxPlayerBullets {
-			bx, by := g.Player.Shoot()
-			bullet := entity.NewBullet(bx, by, g.Player.Dir, g.Player.BulletSpeed, g.Player.PowerLevel, true)
-			g.Bullets = append(g.Bullets, bullet)
-			g.Audio.PlayShoot()
-		}
+		g.updateMenu()
+		return
+	case StateEditor:
+		g.updateEditor()
+		return
+	case StateLobby:
+		g.updateLobby()
+		return
 	}
-
-	if enemy := g.Spawner.Update(dt, g.countAliveEnemies()); enemy != nil {
-		g.Enemies = append(g.Enemies, enemy)
+	if g.testing && g.keyJustPressed(glow.KeyTab) {
+		g.Sim.Stop()
+		g.syncState()
+		return
 	}
 
-	eagleCX, eagleCY := g.Eagle.CenterX(), g.Eagle.CenterY()
-	frozen := g.ClockTimer > 0
-	for _, e := range g.Enemies {
-		if !e.Alive {
-			continue
-		}
-		if frozen {
-			e.UpdateEnemy(dt) // still animate flash, but don't move/shoot
-			continue
-		}
-		others := g.tankBBoxesExcluding(&e.Tank)
-		system.UpdateEnemyAI(e, g.Grid, dt,
-			g.Player.CenterX(), g.Player.CenterY(),
-			eagleCX, eagleCY, others)
-
-		if system.ShouldShoot(e, dt) {
-			bx, by := e.Shoot()
-			bullet := entity.NewBullet(bx, by, e.Dir, e.BulletSpeed, 0, false)
-			g.Bullets = append(g.Bullets, bullet)
+	if g.Net != nil {
+		if !g.netTick(dt, held[0]) {
+			return
 		}
+	} else {
+		g.Sim.Update(dt, g.matchInputs())
 	}
+	g.afterSimTick()
+	g.handleEvents()
+	g.syncState()
 
-	g.Eagle.Update(dt)
-
-	for _, b := range g.Bullets {
-		b.Update(dt)
+	switch g.State {
+	case StatePlaying:
+		g.Particles.Update(dt)
+		g.Shake.Update(dt)
+	case StateGameOver:
+		g.Particles.Update(dt)
 	}
+}
 
-	for _, b := range g.Bullets {
-		system.BulletGridCollision(b, g.Grid, g.Particles)
+// matchInputs returns the inputs of the players in the current match.
+func (g *Game) matchInputs() []*system.Input {
+	if g.Net != nil {
+		return g.netInputs
 	}
+	return g.Inputs[:len(g.Sim.Players)]
+}
 
-	for _, b := range g.Bullets {
-		if !b.Active || !b.IsPlayer {
-			continue
+func (g *Game) updateMenu() {
+	in := g.Inputs[0]
+	if in.IsJustPressed(system.ButtonUp) {
+		g.MenuSelection--
+		if g.MenuSelection < 0 {
+			g.MenuSelection = len(g.MenuOptions) - 1
 		}
-		for _, e := range g.Enemies {
-			if !e.Alive {
-				continue
-			}
-			if system.BulletTankCollision(b, &e.Tank) {
-				b.Active = false
-				destroyed := e.Hit(1)
-				if destroyed {
-					g.Player.Score += e.ScoreValue
-					g.Particles.SpawnExplosion(e.CenterX(), e.CenterY(), 35)
-					g.Audio.PlayExplode()
-					g.Shake.Trigger(0.2, 4)
-					g.trackKill(e.Type)
-					if e.HasPowerUp {
-						g.PowerUps = append(g.PowerUps, entity.NewPowerUp())
-					}
-				}
-				break
+		// Skip disabled options
+		if g.MenuOptions[g.MenuSelection].Disabled {
+			g.MenuSelection--
+			if g.MenuSelection < 0 {
+				g.MenuSelection = len(g.MenuOptions) - 1
 			}
 		}
+		g.Audio.PlayMenuSelect()
 	}
-
-	for _, b := range g.Bullets {
-		if !b.Active || b.IsPlayer {
-			continue
+	if in.IsJustPressed(system.ButtonDown) {
+		g.MenuSelection++
+		if g.MenuSelection >= len(g.MenuOptions) {
+			g.MenuSelection = 0
 		}
-		if g.Player.Alive && !g.Player.IsInvulnerable() {
-			if system.BulletTankCollision(b, &g.Player.Tank) {
-				b.Active = false
-				g.Particles.SpawnExplosion(g.Player.CenterX(), g.Player.CenterY(), 30)
-				g.Audio.PlayExplode()
-				g.Shake.Trigger(0.3, 6)
-				g.Player.Die()
-			}
-		}
-	}
-
-	// Power-up collection
-	if g.Player.Alive {
-		for _, p := range g.PowerUps {
-			if !p.Active {
-				continue
-			}
-			// Simple AABB overlap between player and power-up
-			if g.Player.X < p.X+24 && g.Player.X+float64(config.TankSize) > p.X &&
-				g.Player.Y < p.Y+24 && g.Player.Y+float64(config.TankSize) > p.Y {
-				p.Active = false
-				g.Audio.PlayPowerUp()
-				g.applyPowerUp(p.Type)
+		// Skip disabled options
+		if g.MenuOptions[g.MenuSelection].Disabled {
+			g.MenuSelection++
+			if g.MenuSelection >= len(g.MenuOptions) {
+				g.MenuSelection = 0
 			}
 		}
+		g.Audio.PlayMenuSelect()
 	}
-
-	// Update power-ups
-	for _, p := range g.PowerUps {
-		p.Update(dt)
-	}
-
-	// Clock timer (freeze enemies)
-	if g.ClockTimer > 0 {
-		g.ClockTimer -= dt
-	}
-
-	// Shovel timer (fortification)
-	if g.ShovelTimer > 0 {
-		g.ShovelTimer -= dt
-		if g.ShovelTimer <= 0 {
-			g.unfortifyEagle()
+	if g.MenuSelection == menuDifficulty {
+		switch {
+		case in.IsJustPressed(system.ButtonLeft):
+			g.chooseDifficulty(g.Difficulty + config.DifficultyCount - 1)
+			g.Audio.PlayMenuSelect()
+		case in.IsJustPressed(system.ButtonRight):
+			g.chooseDifficulty(g.Difficulty + 1)
+			g.Audio.PlayMenuSelect()
 		}
 	}
-
-	// Clean up power-ups
-	g.cleanPowerUps()
-
-	if g.Eagle != nil {
-		for y := 0; y < config.GridHeight; y++ {
-			for x := 0; x < config.GridWidth; x++ {
-				if g.Grid.Get(x, y) == world.TileEagleDead {
-					g.Eagle.Alive = false
+	if in.IsJustPressed(system.ButtonConfirm | system.ButtonFire) {
+		if !g.MenuOptions[g.MenuSelection].Disabled {
+			switch g.MenuSelection {
+			case 0: // 1 Player
+				g.StartGame(1)
+			case 1: // 2 Players
+				g.StartGame(2)
+			case 2: // Continue
+				g.ContinueGame()
+			case 3: // Host LAN game
+				if err := g.HostLAN(); err != nil {
+					log.Printf("netplay: %v", err)
 				}
+			case 4: // Join LAN game
+				if err := g.JoinLAN(""); err != nil {
+					log.Printf("netplay: %v", err)
+				}
+			case 5: // Construction
+				if err := g.OpenEditor(DefaultEditorPath()); err != nil {
+					log.Printf("editor: %v", err)
+				}
+			case menuDifficulty:
+				g.chooseDifficulty(g.Difficulty + 1)
+				g.Audio.PlayMenuSelect()
 			}
 		}
 	}
+}
 
-	if !g.Eagle.Alive || (g.Player.Lives <= 0 && !g.Player.Alive) {
+// syncState mirrors the simulation state into the host state machine.
+func (g *Game) syncState() {
+	switch g.Sim.State {
+	case sim.StateLevelIntro:
+		g.State = StateLevelIntro
+	case sim.StatePlaying:
+		g.State = StatePlaying
+	case sim.StatePaused:
+		g.State = StatePaused
+	case sim.StateGameOver:
 		g.State = StateGameOver
-		g.GameOverTimer = 2.0
-		g.Audio.PlayGameOver()
-		g.Shake.Trigger(0.5, 8)
-		g.saveProgress()
-	}
-
-	if g.Spawner.Done() && g.countAliveEnemies() == 0 {
+	case sim.StateLevelComplete:
 		g.State = StateLevelComplete
-		g.LevelComplTimer = 1.5
-		g.saveProgress()
-	}
-
-	g.cleanBullets()
-	g.cleanEnemies()
-	g.Particles.Update(dt)
-	g.Shake.Update(dt)
-
-	// Debug level switching
-	if g.Input.IsJustPressed(glow.KeyN) {
-		next := g.Level + 1
-		if next < len(world.Levels) {
-			g.startLevel(next)
+	case sim.StateEnded:
+		if g.testing {
+			g.endTestPlay()
+			return
+		}
+		g.State = StateMenu
+		g.refreshMenuOptions()
+		g.finishRecording()
+		g.finishPlayback()
+		g.closeNet()
+	}
+}
+
+// handleEvents presents the events raised by the last simulation tick.
+func (g *Game) handleEvents() {
+	g.Audio.SetListener(g.listener())
+	for _, ev := range g.Sim.Events {
+		switch ev.Type {
+		case sim.EventPlayerShoot:
+			g.Audio.PlayShoot(ev.X, ev.Y)
+		case sim.EventEnemyShoot:
+			g.Audio.PlayEnemyShoot(ev.X, ev.Y)
+		case sim.EventEnemyDestroyed:
+			g.Particles.SpawnExplosion(ev.X, ev.Y, 35)
+			g.Audio.PlayExplode(ev.X, ev.Y)
+			g.Shake.Trigger(0.2, 4)
+		case sim.EventEnemyBombed:
+			g.Particles.SpawnExplosion(ev.X, ev.Y, 25)
+			g.Audio.PlayExplode(ev.X, ev.Y)
+		case sim.EventPlayerDestroyed:
+			g.Particles.SpawnExplosion(ev.X, ev.Y, 30)
+			g.Audio.PlayExplode(ev.X, ev.Y)
+			g.Shake.Trigger(0.3, 6)
+		case sim.EventTileDestroyed:
+			g.Particles.SpawnDebris(ev.X, ev.Y)
+			g.Audio.PlayBrickCrumble(ev.X, ev.Y)
+		case sim.EventSteelDestroyed:
+			g.Particles.SpawnDebris(ev.X, ev.Y)
+			g.Audio.PlaySteelHit(ev.X, ev.Y)
+		case sim.EventBulletDeflected:
+			g.Particles.SpawnSpark(ev.X, ev.Y)
+			g.Audio.PlaySteelHit(ev.X, ev.Y)
+		case sim.EventEnemyHit:
+			g.Particles.SpawnSpark(ev.X, ev.Y)
+			g.Audio.PlayArmourHit(ev.X, ev.Y)
+		case sim.EventEnemySpawned:
+			g.Audio.PlayEnemySpawn(ev.X, ev.Y)
+		case sim.EventPowerUpSpawned:
+			g.Audio.PlayPowerUpAppear(ev.X, ev.Y)
+		case sim.EventShieldExpiring:
+			g.Audio.PlayShieldWarning(ev.X, ev.Y)
+		case sim.EventFortifyEnded:
+			g.Audio.PlayFortifyEnd()
+		case sim.EventPlayerRespawned:
+			g.Audio.PlayRespawn(ev.X, ev.Y)
+		case sim.EventEagleDestroyed:
+			g.Particles.SpawnExplosion(ev.X, ev.Y, 40)
+		case sim.EventPowerUpCollected:
+			g.Audio.PlayPowerUp()
+		case sim.EventLevelStart:
+			g.Audio.PlayLevelStart()
+		case sim.EventLevelComplete:
+			if g.keepsProgress() {
+				g.saveProgress()
+			}
+		case sim.EventGameOver:
+			g.Audio.PlayGameOver()
+			g.Shake.Trigger(0.5, 8)
+			g.saveClip()
+			if g.keepsProgress() {
+				g.saveProgress()
+			}
 		}
 	}
 }
 
-func (g *Game) enemyBBoxes() []system.BBox {
-	boxes := make([]system.BBox, 0, len(g.Enemies))
-	for _, e := range g.Enemies {
-		if e.Alive {
-			boxes = append(boxes, system.TankBBox(&e.Tank))
+// listener returns where in the play area sounds are heard from: this
+// instance's own tank in a networked match, otherwise the middle of the
+// local players still in play. With no tank to listen from, it is the
+// eagle.
+func (g *Game) listener() (float64, float64) {
+	s := g.Sim
+	var x, y float64
+	n := 0
+	for _, p := range g.localPlayers() {
+		if p.Alive {
+			x += p.CenterX()
+			y += p.CenterY()
+			n++
 		}
 	}
-	return boxes
-}
-
-func (g *Game) tankBBoxesExcluding(self *entity.Tank) []system.BBox {
-	boxes := make([]system.BBox, 0, len(g.Enemies)+1)
-	if g.Player.Alive {
-		boxes = append(boxes, system.TankBBox(&g.Player.Tank))
+	if n > 0 {
+		return x / float64(n), y / float64(n)
 	}
-	for _, e := range g.Enemies {
-		if e.Alive && &e.Tank != self {
-			boxes = append(boxes, system.TankBBox(&e.Tank))
-		}
+	if s.Eagle != nil {
+		return s.Eagle.CenterX(), s.Eagle.CenterY()
 	}
-	return boxes
+	return config.PlayAreaWidth / 2, config.PlayAreaHeight / 2
 }
 
-func (g *Game) countAliveEnemies() int {
-	count := 0
-	for _, e := range g.Enemies {
-		if e.Alive {
-			count++
-		}
+// localPlayers returns the players driven from this instance.
+func (g *Game) localPlayers() []*entity.PlayerTank {
+	players := g.Sim.Players
+	if g.Net != nil && g.Net.Local < len(players) {
+		return players[g.Net.Local : g.Net.Local+1]
 	}
-	return count
+	return players
 }
 
-func (g *Game) cleanBullets() {
-	n := 0
-	for _, b := range g.Bullets {
-		if b.Active {
-			g.Bullets[n] = b
-			n++
-		}
-	}
-	g.Bullets = g.Bullets[:n]
-}
+// menuDifficulty is the index of the menu option that picks the difficulty.
+const menuDifficulty = 6
 
 func (g *Game) refreshMenuOptions() {
-	g.MenuOptions[1].Disabled = g.SaveData.MaxLevel == 0
+	g.MenuOptions[2].Disabled = !g.builtinCampaign() || g.SaveData.For(g.Difficulty).MaxLevel == 0
+	g.MenuOptions[menuDifficulty].Label = "DIFFICULTY: " + g.Difficulty.String()
 }
 
-func (g *Game) saveProgress() {
-	if g.Player.Score > g.SaveData.HighScore {
-		g.SaveData.HighScore = g.Player.Score
-	}
-	if g.Level+1 > g.SaveData.MaxLevel {
-		g.SaveData.MaxLevel = g.Level + 1
-	}
-	save.Save(g.SaveData)
+// SetDifficulty picks the preset for new matches, wrapping around past
+// either end. It is not saved; see chooseDifficulty.
+func (g *Game) SetDifficulty(d config.Difficulty) {
+	g.Difficulty = d % config.DifficultyCount
+	g.refreshMenuOptions()
 }
 
-func (g *Game) trackKill(typ entity.EnemyType) {
-	switch typ {
-	case entity.EnemyBasic:
-		g.KillsBasic++
-	case entity.EnemyFast:
-		g.KillsFast++
-	case entity.EnemyPower:
-		g.KillsPower++
-	case entity.EnemyArmour:
-		g.KillsArmour++
-	}
+// chooseDifficulty sets the preset picked in the menu and remembers it as
+// the default for later sessions.
+func (g *Game) chooseDifficulty(d config.Difficulty) {
+	g.SetDifficulty(d)
+	g.SaveData.Difficulty = strings.ToLower(g.Difficulty.String())
+	save.Save(g.SaveData)
 }
 
-func (g *Game) cleanPowerUps() {
-	n := 0
-	for _, p := range g.PowerUps {
-		if p.Active {
-			g.PowerUps[n] = p
-			n++
-		}
-	}
-	g.PowerUps = g.PowerUps[:n]
+// SetLevels plays levels in place of the built-in campaign.
+func (g *Game) SetLevels(levels []*world.Level) {
+	g.Sim.Levels = levels
+	g.refreshMenuOptions()
 }
 
-func (g *Game) applyPowerUp(typ entity.PowerUpType) {
-	switch typ {
-	case entity.PowerUpStar:
-		g.Player.ApplyStar()
-	case entity.PowerUpTank:
-		g.Player.Lives++
-	case entity.PowerUpHelmet:
-		g.Player.ShieldTimer = config.PowerUpDuration
-	case entity.PowerUpShovel:
-		g.fortifyEagle()
-		g.ShovelTimer = config.PowerUpDuration
-	case entity.PowerUpBomb:
-		for _, e := range g.Enemies {
-			if e.Alive {
-				e.Alive = false
-				g.Player.Score += e.ScoreValue
-				g.Particles.SpawnExplosion(e.CenterX(), e.CenterY(), 25)
-			}
-		}
-	case entity.PowerUpClock:
-		g.ClockTimer = config.PowerUpDuration
-	}
-	g.Player.Score += 500
+// builtinCampaign reports whether the levels being played are the built-in
+// campaign, the only one whose progress is saved.
+func (g *Game) builtinCampaign() bool {
+	return world.HashLevels(g.Sim.Levels) == world.HashLevels(world.Levels)
 }
 
-func (g *Game) fortifyEagle() {
-	if g.Eagle == nil {
-		return
-	}
-	// Replace brick around eagle with steel
-	ex := int(g.Eagle.X) / config.SubBlock
-	ey := int(g.Eagle.Y) / config.SubBlock
-	for dy := -1; dy <= 2; dy++ {
-		for dx := -1; dx <= 2; dx++ {
-			gx, gy := ex+dx, ey+dy
-			tile := g.Grid.Get(gx, gy)
-			if tile == world.TileBrick || tile == world.TileEmpty {
-				// Only fortify the border cells
-				if dx == -1 || dx == 2 || dy == -1 || dy == 2 {
-					g.Grid.Set(gx, gy, world.TileSteel)
-				}
-			}
-		}
-	}
-	g.Eagle.Fortified = true
+// keepsProgress reports whether the match in progress counts towards the
+// save file: test plays, replays, networked matches, other campaigns and
+// games run with NoSave do not.
+func (g *Game) keepsProgress() bool {
+	return !g.NoSave && !g.testing && g.Playback == nil && g.Net == nil && g.builtinCampaign()
 }
 
-func (g *Game) unfortifyEagle() {
-	if g.Eagle == nil {
-		return
+// saveProgress records the match's score and level against the difficulty
+// it was played on.
+func (g *Game) saveProgress() {
+	p := g.SaveData.For(g.Sim.Difficulty)
+	if score := g.Sim.Score(); score > p.HighScore {
+		p.HighScore = score
 	}
-	ex := int(g.Eagle.X) / config.SubBlock
-	ey := int(g.Eagle.Y) / config.SubBlock
-	for dy := -1; dy <= 2; dy++ {
-		for dx := -1; dx <= 2; dx++ {
-			gx, gy := ex+dx, ey+dy
-			tile := g.Grid.Get(gx, gy)
-			if tile == world.TileSteel {
-				if dx == -1 || dx == 2 || dy == -1 || dy == 2 {
-					g.Grid.Set(gx, gy, world.TileBrick)
-				}
-			}
-		}
+	if g.Sim.Level+1 > p.MaxLevel {
+		p.MaxLevel = g.Sim.Level + 1
 	}
-	g.Eagle.Fortified = false
-}
-
-func (g *Game) cleanEnemies() {
-	n := 0
-	for _, e := range g.Enemies {
-		if e.Alive {
-			g.Enemies[n] = e
-			n++
-		}
-	}
-	g.Enemies = g.Enemies[:n]
+	save.Save(g.SaveData)
 }
 
 func enemyColors(typ entity.EnemyType) render.TankColors {
@@ -581,13 +591,19 @@ func enemyColors(typ entity.EnemyType) render.TankColors {
 	}
 }
 
-// Draw renders the current game state.
-func (g *Game) Draw(canvas *glow.Canvas) {
-	sc := render.NewScaledCanvas(canvas, g.Layout)
+// Draw renders the current game state onto canvas, laid out for the window.
+func (g *Game) Draw(canvas render.Target) {
+	g.draw(render.NewScaledCanvas(canvas, g.Layout))
+}
 
+func (g *Game) draw(sc *render.ScaledCanvas) {
 	switch g.State {
 	case StateMenu:
 		g.drawMenu(sc)
+	case StateEditor:
+		g.drawEditor(sc)
+	case StateLobby:
+		g.drawLobby(sc)
 	case StateLevelIntro:
 		g.drawLevelIntro(sc)
 	case StatePlaying, StatePaused:
@@ -596,6 +612,12 @@ func (g *Game) Draw(canvas *glow.Canvas) {
 		if g.State == StatePaused {
 			g.drawPauseOverlay(sc)
 		}
+		if g.Net != nil {
+			g.drawNetStatus(sc)
+		}
+		if g.ShowDirector {
+			g.drawDirector(sc)
+		}
 	case StateGameOver:
 		g.drawPlayField(sc)
 		g.drawHUD(sc)
@@ -608,48 +630,77 @@ func (g *Game) Draw(canvas *glow.Canvas) {
 }
 
 func (g *Game) drawPlayField(canvas *render.ScaledCanvas) {
+	s := g.Sim
 	ox := config.Padding + g.Shake.OffsetX
 	oy := config.Padding + g.Shake.OffsetY
 
 	g.Renderer.DrawPlayAreaBorder(canvas)
 	g.Renderer.OffsetX = ox
 	g.Renderer.OffsetY = oy
-	g.Renderer.DrawGrid(canvas, g.Grid)
+	g.Renderer.DrawGrid(canvas, s.Grid)
 
-	for _, e := range g.Enemies {
+	for _, e := range s.Enemies {
 		colors := enemyColors(e.Type)
 		if e.IsFlashing() {
 			colors = render.TankColors{Body: render.ColorWhite, Tread: render.ColorYellow, Dark: render.ColorGray}
 		}
-		render.DrawTank(canvas, &e.Tank, colors, ox, oy)
+		render.DrawTank(canvas, &e.Tank, colors, ox, oy, g.Alpha)
 	}
 
-	if g.Player.Alive {
-		render.DrawTank(canvas, &g.Player.Tank, render.PlayerColors, ox, oy)
-		if g.Player.IsInvulnerable() {
-			render.DrawShield(canvas, &g.Player.Tank, ox, oy, g.Time)
+	for _, p := range s.Players {
+		if !p.Alive {
+			continue
+		}
+		render.DrawTank(canvas, &p.Tank, render.PlayerTankColors(p.Index), ox, oy, g.Alpha)
+		if p.IsInvulnerable() {
+			render.DrawShield(canvas, &p.Tank, ox, oy, g.Alpha, g.Time)
 		}
 	}
 
-	for _, b := range g.Bullets {
-		render.DrawBullet(canvas, b, ox, oy)
+	for _, b := range s.Bullets {
+		render.DrawBullet(canvas, b, ox, oy, g.Alpha)
 	}
 
-	for _, p := range g.PowerUps {
+	for _, p := range s.PowerUps {
 		render.DrawPowerUp(canvas, p, ox, oy)
 	}
 
 	g.Particles.Draw(canvas, ox, oy)
-	g.Renderer.DrawForest(canvas, g.Grid)
+	g.Renderer.DrawForest(canvas, s.Grid)
 
 	// Reset offsets
 	g.Renderer.OffsetX = config.Padding
 	g.Renderer.OffsetY = config.Padding
 }
 
+func (g *Game) drawDirector(canvas *render.ScaledCanvas) {
+	d := &g.Sim.Director
+	p := g.Sim.Spawner.Preset
+	render.DrawDirectorOverlay(canvas, render.DirectorStatus{
+		Enabled:       d.Enabled,
+		Intensity:     d.Intensity,
+		Target:        d.Target,
+		Deaths:        d.Deaths,
+		KillRate:      d.KillRate(),
+		Pace:          d.Pace,
+		Walls:         d.Walls,
+		SpawnInterval: d.SpawnInterval(p.SpawnInterval),
+		MaxActive:     d.MaxActive(p.MaxActiveEnemies),
+		PowerUpEvery:  d.PowerUpEvery(p.PowerUpEvery),
+	})
+}
+
 func (g *Game) drawHUD(canvas *render.ScaledCanvas) {
-	remaining := g.Spawner.Remaining() + g.countAliveEnemies()
-	g.HUD.DrawHUD(canvas, remaining, g.Player.Lives, g.Level, g.Player.Score, g.Audio.Muted)
+	s := g.Sim
+	timeLeft := -1.0
+	if s.Def.TimeLimit > 0 {
+		timeLeft = math.Max(0, s.Def.TimeLimit-s.LevelTime)
+	}
+	players := make([]render.PlayerStatus, len(s.Players))
+	for i, p := range s.Players {
+		players[i] = render.PlayerStatus{Lives: p.Lives, Score: p.Score, Stars: p.Stars}
+	}
+	g.HUD.DrawHUD(canvas, s.EnemiesRemaining(), players, s.Level, timeLeft, g.Audio.Muted)
 }
 
 func (g *Game) drawMenu(canvas *render.ScaledCanvas) {
@@ -657,7 +708,7 @@ func (g *Game) drawMenu(canvas *render.ScaledCanvas) {
 }
 
 func (g *Game) drawLevelIntro(canvas *render.ScaledCanvas) {
-	render.DrawLevelIntro(canvas, g.Level)
+	render.DrawLevelIntro(canvas, g.Sim.Level, g.Sim.Def.Name)
 }
 
 func (g *Game) drawPauseOverlay(canvas *render.ScaledCanvas) {
@@ -665,11 +716,12 @@ func (g *Game) drawPauseOverlay(canvas *render.ScaledCanvas) {
 }
 
 func (g *Game) drawGameOver(canvas *render.ScaledCanvas) {
-	render.DrawGameOverScreen(canvas, g.Player.Score, g.GameOverTimer <= 0, g.Time)
+	render.DrawGameOverScreen(canvas, g.Sim.Score(), g.Sim.GameOverTimer <= 0, g.Time)
 }
 
 func (g *Game) drawLevelComplete(canvas *render.ScaledCanvas) {
-	render.DrawLevelComplete(canvas, g.Level, g.Player.Score,
-		g.KillsBasic, g.KillsFast, g.KillsPower, g.KillsArmour,
-		g.LevelComplTimer <= 0, g.Time)
+	s := g.Sim
+	render.DrawLevelComplete(canvas, s.Level, s.Score(),
+		s.KillsBasic, s.KillsFast, s.KillsPower, s.KillsArmour,
+		s.LevelComplTimer <= 0, g.Time)
 }
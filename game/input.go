@@ -0,0 +1,82 @@
+package game
+
+import (
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/glow"
+)
+
+// hostBindings are the menu, pause and audio keys. They always drive 1P's
+// input, which is the one menus read.
+var hostBindings = map[glow.Key]system.Button{
+	glow.KeyEscape: system.ButtonPause,
+	glow.KeyEnter:  system.ButtonConfirm,
+	glow.KeyN:      system.ButtonSkipLevel,
+	glow.KeyM:      system.ButtonMute,
+	glow.KeyEqual:  system.ButtonVolumeUp,
+	glow.KeyMinus:  system.ButtonVolumeDown,
+	glow.Key0:      system.ButtonMusicUp,
+	glow.Key9:      system.ButtonMusicDown,
+}
+
+// soloBindings let a lone player use either WASD or the arrow keys.
+var soloBindings = map[glow.Key]system.Button{
+	glow.KeyW:     system.ButtonUp,
+	glow.KeyUp:    system.ButtonUp,
+	glow.KeyS:     system.ButtonDown,
+	glow.KeyDown:  system.ButtonDown,
+	glow.KeyA:     system.ButtonLeft,
+	glow.KeyLeft:  system.ButtonLeft,
+	glow.KeyD:     system.ButtonRight,
+	glow.KeyRight: system.ButtonRight,
+	glow.KeySpace: system.ButtonFire,
+}
+
+// coopBindings split the keyboard between the players of a co-op match.
+var coopBindings = [config.MaxLocalPlayers]map[glow.Key]system.Button{
+	{
+		glow.KeyW:     system.ButtonUp,
+		glow.KeyS:     system.ButtonDown,
+		glow.KeyA:     system.ButtonLeft,
+		glow.KeyD:     system.ButtonRight,
+		glow.KeySpace: system.ButtonFire,
+	},
+	{
+		glow.KeyUp:           system.ButtonUp,
+		glow.KeyDown:         system.ButtonDown,
+		glow.KeyLeft:         system.ButtonLeft,
+		glow.KeyRight:        system.ButtonRight,
+		glow.KeyRightControl: system.ButtonFire,
+	},
+}
+
+// buttonsFromKeys returns the buttons pressed by the currently held keys.
+func buttonsFromKeys(keys map[glow.Key]bool, bindings ...map[glow.Key]system.Button) system.Button {
+	var held system.Button
+	for key, down := range keys {
+		if !down {
+			continue
+		}
+		for _, b := range bindings {
+			held |= b[key]
+		}
+	}
+	return held
+}
+
+// heldButtons returns the buttons each player is holding. Outside a local
+// co-op match every key drives 1P, which in a networked match is whichever
+// player this instance controls.
+func (g *Game) heldButtons() []system.Button {
+	held := make([]system.Button, config.MaxPlayers)
+	if g.State == StateMenu || g.State == StateEditor || g.State == StateLobby ||
+		g.Net != nil || len(g.Sim.Players) == 1 {
+		held[0] = buttonsFromKeys(g.Keys, hostBindings, soloBindings)
+		return held
+	}
+	for i, b := range coopBindings {
+		held[i] = buttonsFromKeys(g.Keys, b)
+	}
+	held[0] |= buttonsFromKeys(g.Keys, hostBindings)
+	return held
+}
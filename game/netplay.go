@@ -0,0 +1,226 @@
+package game
+
+import (
+	"log"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/netplay"
+	"github.com/AchrafSoltani/TankStrike/render"
+	"github.com/AchrafSoltani/TankStrike/replay"
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+// netStallBanner is how long the match must wait on the network before
+// the waiting banner is shown, so ordinary jitter does not flash it.
+const netStallBanner = 0.25 // seconds
+
+// HostLAN opens a lobby that other instances on the LAN can join.
+func (g *Game) HostLAN() error {
+	h, err := netplay.NewHost(g.NetPort, config.Version)
+	if err != nil {
+		return err
+	}
+	h.Delay = g.NetDelay
+	h.Difficulty = g.Difficulty
+	h.Adaptive = g.Adaptive
+	g.Host = h
+	g.State = StateLobby
+	return nil
+}
+
+// JoinLAN looks for hosts on the LAN. If addr is not empty it joins the
+// host there directly instead of waiting for one to be picked.
+func (g *Game) JoinLAN(addr string) error {
+	c, err := netplay.NewClient(g.NetPort, config.Version)
+	if err != nil {
+		return err
+	}
+	if addr != "" {
+		if err := c.Join(addr); err != nil {
+			c.Close()
+			return err
+		}
+	}
+	g.Client = c
+	g.LobbySelection = 0
+	g.State = StateLobby
+	return nil
+}
+
+func (g *Game) updateLobby() {
+	in := g.Inputs[0]
+	if in.IsJustPressed(system.ButtonPause) {
+		g.leaveLobby()
+		return
+	}
+
+	if h := g.Host; h != nil {
+		h.Poll()
+		switch {
+		case in.IsJustPressed(system.ButtonUp | system.ButtonDown):
+			h.Rollback = !h.Rollback
+		case in.IsJustPressed(system.ButtonLeft):
+			h.Delay = max(0, h.Delay-1)
+		case in.IsJustPressed(system.ButtonRight):
+			h.Delay = min(netplay.MaxDelay, h.Delay+1)
+		case in.IsJustPressed(system.ButtonConfirm) && h.Players() > 1:
+			g.NetDelay = h.Delay
+			g.Net = h.Start(g.Sim.Seed, 0)
+			g.Host = nil
+			g.startNetMatch()
+		}
+		return
+	}
+
+	c := g.Client
+	if ls := c.Poll(); ls != nil {
+		g.Net = ls
+		g.Client = nil
+		g.startNetMatch()
+		return
+	}
+	if c.Joined != nil || len(c.Hosts) == 0 {
+		return
+	}
+	g.LobbySelection = min(g.LobbySelection, len(c.Hosts)-1)
+	switch {
+	case in.IsJustPressed(system.ButtonUp):
+		g.LobbySelection = (g.LobbySelection + len(c.Hosts) - 1) % len(c.Hosts)
+		g.Audio.PlayMenuSelect()
+	case in.IsJustPressed(system.ButtonDown):
+		g.LobbySelection = (g.LobbySelection + 1) % len(c.Hosts)
+		g.Audio.PlayMenuSelect()
+	case in.IsJustPressed(system.ButtonConfirm | system.ButtonFire):
+		if err := c.Join(c.Hosts[g.LobbySelection].Addr.String()); err != nil {
+			log.Printf("netplay: %v", err)
+		}
+	}
+}
+
+func (g *Game) leaveLobby() {
+	if g.Host != nil {
+		g.Host.Close()
+		g.Host = nil
+	}
+	if g.Client != nil {
+		g.Client.Close()
+		g.Client = nil
+	}
+	g.State = StateMenu
+	g.refreshMenuOptions()
+}
+
+// startNetMatch starts the match a lockstep session was opened for. Every
+// instance uses the host's seed and difficulty, so they all simulate the
+// same match.
+func (g *Game) startNetMatch() {
+	mode := "lockstep"
+	if g.Net.Rollback {
+		mode = "rollback"
+	}
+	log.Printf("netplay: starting %s match on %s as %dP of %d, %d ticks input delay",
+		mode, g.Net.Difficulty, g.Net.Local+1, g.Net.Players, g.Net.Delay)
+	g.Players = g.Net.Players
+	g.netInputs = newInputs()[:g.Net.Players]
+	g.Sim.Seed = g.Net.Seed
+	g.Sim.Difficulty = g.Net.Difficulty
+	g.Sim.Adaptive = g.Net.Adaptive
+	g.Sim.Start(g.Net.Level, g.Net.Players)
+	if g.Net.Rollback {
+		// A rollback match simulates predicted input that may later be
+		// corrected, so its ticks cannot be recorded as they run.
+		g.Rollback = netplay.NewRollback(g.Net, g.Sim)
+		g.netInputs = g.Rollback.Inputs()
+	} else {
+		g.beginRecording()
+	}
+	g.handleEvents()
+	g.syncState()
+}
+
+// netTick exchanges input with the other instances and simulates the
+// next tick: in lockstep once every player's input for it has arrived,
+// with rollback as long as the match is not too far ahead of that input.
+// It reports whether a tick was simulated.
+func (g *Game) netTick(dt float64, local system.Button) bool {
+	local &^= system.HostButtons
+	desynced := g.Net.DesyncTick != 0
+	var ok bool
+	if g.Rollback != nil {
+		ok = g.Rollback.Advance(dt, local)
+	} else {
+		ok = g.lockstepTick(dt, local)
+	}
+	if !desynced && g.Net.DesyncTick != 0 {
+		log.Printf("netplay: desync at tick %d", g.Net.DesyncTick)
+	}
+	if err := g.Net.Err(); err != nil {
+		log.Printf("%v", err)
+		g.Sim.Stop()
+		g.syncState()
+		return false
+	}
+	return ok
+}
+
+func (g *Game) lockstepTick(dt float64, local system.Button) bool {
+	buttons, ok := g.Net.Advance(local)
+	if !ok {
+		return false
+	}
+	for i, in := range g.netInputs {
+		in.Update(buttons[i])
+	}
+	g.Sim.Update(dt, g.netInputs)
+	if g.Sim.Tick%replay.CheckInterval == 0 {
+		g.Net.Check(g.Sim.Tick, g.Sim.Checksum())
+	}
+	return true
+}
+
+// closeNet leaves the networked match, if any.
+func (g *Game) closeNet() {
+	if g.Net == nil {
+		return
+	}
+	if g.Rollback != nil {
+		log.Printf("netplay: %d rollbacks, %d ticks simulated again", g.Rollback.Rollbacks, g.Rollback.Resimulated)
+		g.Rollback = nil
+	}
+	g.Net.Close()
+	g.Net = nil
+	g.netInputs = nil
+}
+
+func (g *Game) drawLobby(canvas *render.ScaledCanvas) {
+	if h := g.Host; h != nil {
+		peers := h.Peers()
+		names := make([]string, len(peers))
+		for i, p := range peers {
+			names[i] = p.String()
+		}
+		render.DrawHostLobby(canvas, h.Port(), names, h.Watchers(), h.Rollback, h.Delay, h.Difficulty.String(), g.Time)
+		return
+	}
+
+	c := g.Client
+	hosts := make([]string, len(c.Hosts))
+	for i, h := range c.Hosts {
+		hosts[i] = h.Addr.String()
+	}
+	joined := ""
+	if c.Joined != nil {
+		joined = c.Joined.String()
+	}
+	render.DrawJoinLobby(canvas, hosts, g.LobbySelection, joined, c.Index, c.Players, c.Rejected, g.Time)
+}
+
+// drawNetStatus overlays the state of a networked match on the play field.
+func (g *Game) drawNetStatus(canvas *render.ScaledCanvas) {
+	switch {
+	case g.Net.DesyncTick != 0:
+		render.DrawNetBanner(canvas, "DESYNC", render.ColorRed)
+	case g.Net.Stalled().Seconds() >= netStallBanner:
+		render.DrawNetBanner(canvas, "WAITING FOR PLAYERS", render.ColorYellow)
+	}
+}
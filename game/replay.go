@@ -0,0 +1,89 @@
+package game
+
+import (
+	"log"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/replay"
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+// PlayReplay loads a replay file and starts driving a match from it.
+func (g *Game) PlayReplay(path string) error {
+	r, err := replay.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := r.CheckLevels(g.Sim.Levels); err != nil {
+		return err
+	}
+	if r.Version != config.Version {
+		log.Printf("replay: recorded with version %s, running %s; playback may desync", r.Version, config.Version)
+	}
+	g.Players = r.Players
+	g.Playback = replay.NewPlayback(r)
+	g.Playback.Start(g.Sim, g.Inputs)
+	g.handleEvents()
+	g.syncState()
+	return nil
+}
+
+func (g *Game) beginRecording() {
+	if g.RecordPath == "" {
+		return
+	}
+	g.Recorder = replay.NewRecorder(config.Version, g.Sim, g.recordedButtons())
+}
+
+// recordedButtons returns the gameplay buttons held by each player in the
+// match; host buttons such as mute are not part of a replay.
+func (g *Game) recordedButtons() []system.Button {
+	inputs := g.matchInputs()
+	held := make([]system.Button, len(inputs))
+	for i, in := range inputs {
+		held[i] = in.Held &^ system.HostButtons
+	}
+	return held
+}
+
+// afterSimTick records or verifies the tick just simulated.
+func (g *Game) afterSimTick() {
+	if g.Recorder != nil {
+		g.Recorder.Record(g.recordedButtons(), g.Sim)
+	}
+	if g.Playback != nil && !g.Playback.Verify(g.Sim) {
+		log.Printf("replay: desync at tick %d", g.Playback.DesyncTick)
+	}
+}
+
+func (g *Game) finishRecording() {
+	if g.Recorder == nil {
+		return
+	}
+	if err := g.Recorder.Save(g.RecordPath); err != nil {
+		log.Printf("replay: failed to save %s: %v", g.RecordPath, err)
+	} else {
+		log.Printf("replay: saved %d ticks to %s", len(g.Recorder.Replay.Inputs), g.RecordPath)
+	}
+	g.Recorder = nil
+}
+
+func (g *Game) finishPlayback() {
+	if g.Playback == nil {
+		return
+	}
+	if g.Playback.DesyncTick == 0 {
+		log.Printf("replay: finished after %d ticks, no desync", g.Playback.Tick)
+	}
+	g.Playback = nil
+}
+
+// Close flushes any recording in progress and leaves any networked match
+// or lobby, and stops audio. Call it before exiting.
+func (g *Game) Close() {
+	g.finishRecording()
+	g.closeNet()
+	g.leaveLobby()
+	g.closeCapture()
+	g.Audio.Close()
+}
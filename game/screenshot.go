@@ -0,0 +1,46 @@
+package game
+
+import (
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/render"
+	"github.com/AchrafSoltani/TankStrike/save"
+)
+
+// ScreenshotDir returns the directory F12 saves screenshots to and F9
+// saves clips to.
+func ScreenshotDir() string {
+	return filepath.Join(save.Dir(), "screenshots")
+}
+
+// Screenshot renders the current game state offscreen at the game's native
+// resolution, whatever the size of the window.
+func (g *Game) Screenshot() *image.RGBA {
+	t := render.NewImageTarget(config.WindowWidth, config.WindowHeight)
+	g.draw(render.NewScaledCanvas(t, config.NewLayout(config.WindowWidth, config.WindowHeight)))
+	return t.Image()
+}
+
+// saveScreenshot writes a screenshot to a time-stamped PNG in
+// ScreenshotDir. The encoding happens off the game loop.
+func (g *Game) saveScreenshot() {
+	img := g.Screenshot()
+	dir := ScreenshotDir()
+	path := filepath.Join(dir, time.Now().Format("tankstrike-20060102-150405.000.png"))
+	go func() {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Printf("screenshot: %v", err)
+			return
+		}
+		if err := render.SavePNG(path, img); err != nil {
+			log.Printf("screenshot: %v", err)
+			return
+		}
+		log.Printf("screenshot saved to %s", path)
+	}()
+}
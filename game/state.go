@@ -10,4 +10,6 @@ const (
 	StateGameOver
 	StateLevelComplete
 	StateLevelIntro
+	StateEditor
+	StateLobby
 )
@@ -1,22 +1,119 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
 	"time"
 
+	"github.com/AchrafSoltani/TankStrike/audio"
 	"github.com/AchrafSoltani/TankStrike/config"
 	"github.com/AchrafSoltani/TankStrike/game"
+	"github.com/AchrafSoltani/TankStrike/netplay"
+	"github.com/AchrafSoltani/TankStrike/world"
 	"github.com/AchrafSoltani/glow"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "netcheck":
+			os.Exit(runNetcheck(os.Args[2:]))
+		case "sfx":
+			os.Exit(runSFX(os.Args[2:]))
+		case "screenshot":
+			os.Exit(runScreenshot(os.Args[2:]))
+		case "term":
+			os.Exit(runTerm(os.Args[2:]))
+		}
+	}
+
+	seed := flag.Uint64("seed", 0, "gameplay random seed (0 picks one from the clock)")
+	record := flag.String("record", "", "save a replay of each match to this .tsr file")
+	replayPath := flag.String("replay", "", "play back a .tsr replay file")
+	levelDir := flag.String("levels", "", "play the .lvl level files in this directory instead of the built-in campaign")
+	audioOut := flag.String("audio", "glow", "audio output: glow for the speakers, null for none, or a .wav file to record to")
+	sfxDir := flag.String("sfx", "", "play the .sfx sound effect files in this directory in place of the built-in ones")
+	editPath := flag.String("edit", "", "open this .lvl file in the level editor")
+	host := flag.Bool("host", false, "host a LAN game")
+	rollback := flag.Bool("rollback", false, "run hosted LAN games with rollback instead of lockstep")
+	join := flag.String("join", "", "join the LAN game hosted at this address (host:port)")
+	port := flag.Int("port", netplay.DefaultPort, "UDP port to host LAN games on, or look for them on")
+	delay := flag.Int("delay", netplay.DefaultDelay, "input delay in ticks for hosted LAN games")
+	difficulty := flag.String("difficulty", "", "difficulty preset: easy, normal, hard or nightmare (default: the last one chosen)")
+	capture := flag.Float64("capture", 0, "keep this many seconds of play to save as a GIF with F9 or at game over (0 is off)")
+	director := flag.Bool("director", false, "let the adaptive director pace enemies to how the players are doing")
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+	log.Printf("seed %d", *seed)
+
 	win, err := glow.NewWindow("TankStrike", config.WindowWidth, config.WindowHeight)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer win.Close()
 
-	g := game.NewGame()
+	out, err := audio.OpenOutput(*audioOut)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g := game.NewGame(*seed, out)
+	defer g.Close()
+	g.RecordPath = *record
+	g.NetPort = *port
+	if *delay < 0 || *delay > netplay.MaxDelay {
+		log.Fatalf("--delay must be 0-%d", netplay.MaxDelay)
+	}
+	g.NetDelay = *delay
+	g.Adaptive = *director
+	if *capture > 0 {
+		g.EnableCapture(*capture)
+	}
+	if *difficulty != "" {
+		d, ok := config.ParseDifficulty(*difficulty)
+		if !ok {
+			log.Fatalf("unknown difficulty %q", *difficulty)
+		}
+		g.SetDifficulty(d)
+	}
+	if *levelDir != "" {
+		levels, err := world.LoadLevelDir(*levelDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.SetLevels(levels)
+	}
+	if *sfxDir != "" {
+		if err := g.Audio.LoadEffects(*sfxDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *replayPath != "" {
+		if err := g.PlayReplay(*replayPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *editPath != "" {
+		if err := g.OpenEditor(*editPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *host {
+		if err := g.HostLAN(); err != nil {
+			log.Fatal(err)
+		}
+		g.Host.Rollback = *rollback
+	}
+	if *join != "" {
+		if err := g.JoinLAN(*join); err != nil {
+			log.Fatal(err)
+		}
+	}
 	canvas := win.Canvas()
 	running := true
 	lastTime := time.Now()
@@ -26,10 +123,6 @@ func main() {
 		dt := now.Sub(lastTime).Seconds()
 		lastTime = now
 
-		if dt > 0.05 {
-			dt = 0.05
-		}
-
 		for {
 			event := win.PollEvent()
 			if event == nil {
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/netplay"
+	"github.com/AchrafSoltani/TankStrike/replay"
+	"github.com/AchrafSoltani/TankStrike/sim"
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+// netcheckResult is how one instance of a netcheck match ended.
+type netcheckResult struct {
+	player      int
+	sum         uint64 // checksum at the target tick
+	stalls      int    // ticks spent waiting for remote input
+	rollbacks   int
+	resimulated int
+	desync      uint64
+	err         error
+}
+
+// runNetcheck implements "tankstrike netcheck". It plays a match between
+// bots in one process, each client reaching the host through a Proxy that
+// adds latency and packet loss, and reports whether every instance
+// simulated the same match. It returns the process exit code.
+func runNetcheck(args []string) int {
+	fs := flag.NewFlagSet("netcheck", flag.ExitOnError)
+	players := fs.Int("players", 2, "instances in the match (2-4)")
+	mode := fs.String("mode", "rollback", "session type: lockstep or rollback")
+	delay := fs.Int("delay", netplay.DefaultDelay, "input delay in ticks")
+	latency := fs.Duration("latency", 50*time.Millisecond, "one-way latency added to every packet")
+	jitter := fs.Duration("jitter", 10*time.Millisecond, "random extra latency of up to this much")
+	loss := fs.Float64("loss", 0.05, "fraction of packets dropped")
+	ticks := fs.Int("ticks", 1800, "ticks to play, rounded up to a whole checksum interval")
+	seed := fs.Uint64("seed", 1, "gameplay and bot random seed")
+	difficultyName := fs.String("difficulty", "normal", "difficulty preset: easy, normal, hard or nightmare")
+	director := fs.Bool("director", false, "run the adaptive director")
+	fs.Parse(args)
+
+	if *players < 2 || *players > config.MaxPlayers {
+		fmt.Fprintf(os.Stderr, "netcheck: --players must be 2-%d\n", config.MaxPlayers)
+		return 2
+	}
+	if *delay < 0 || *delay > netplay.MaxDelay {
+		fmt.Fprintf(os.Stderr, "netcheck: --delay must be 0-%d\n", netplay.MaxDelay)
+		return 2
+	}
+	if *mode != "lockstep" && *mode != "rollback" {
+		fmt.Fprintln(os.Stderr, "netcheck: --mode must be lockstep or rollback")
+		return 2
+	}
+	difficulty, known := config.ParseDifficulty(*difficultyName)
+	if !known {
+		fmt.Fprintln(os.Stderr, "netcheck: --difficulty must be easy, normal, hard or nightmare")
+		return 2
+	}
+	target := (uint64(*ticks) + replay.CheckInterval - 1) / replay.CheckInterval * replay.CheckInterval
+
+	host, err := netplay.NewHost(0, config.Version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "netcheck:", err)
+		return 1
+	}
+	host.Delay = *delay
+	host.Rollback = *mode == "rollback"
+	host.Difficulty = difficulty
+	host.Adaptive = *director
+	hostAddr := fmt.Sprintf("127.0.0.1:%d", host.Port())
+
+	var clients []*netplay.Client
+	for i := 1; i < *players; i++ {
+		proxy, err := netplay.NewProxy(hostAddr, *latency, *jitter, *loss, *seed+uint64(i))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "netcheck:", err)
+			return 1
+		}
+		defer proxy.Close()
+		c, err := netplay.NewClient(0, config.Version)
+		if err == nil {
+			err = c.Join(proxy.Addr())
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "netcheck:", err)
+			return 1
+		}
+		clients = append(clients, c)
+	}
+
+	fmt.Printf("%d players, %s, %s, %d ticks input delay, %v latency (+%v jitter), %.0f%% loss\n",
+		*players, *mode, difficulty, *delay, *latency, *jitter, *loss*100)
+	deadline := time.Now().Add(10 * time.Second)
+	for host.Players() < *players {
+		if time.Now().After(deadline) {
+			fmt.Fprintf(os.Stderr, "netcheck: only %d of %d players joined\n", host.Players(), *players)
+			return 1
+		}
+		host.Poll()
+		for _, c := range clients {
+			c.Poll()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		finished atomic.Int32
+		results  = make([]netcheckResult, *players)
+	)
+	play := func(ls *netplay.Lockstep) {
+		defer wg.Done()
+		results[ls.Local] = playNetcheck(ls, target, *seed, &finished, int32(*players))
+	}
+	wg.Add(*players)
+	go play(host.Start(*seed, 0))
+	for _, c := range clients {
+		go func() {
+			for {
+				if ls := c.Poll(); ls != nil {
+					play(ls)
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	ok := true
+	for _, r := range results {
+		fmt.Printf("%dP  checksum %016x  stalled %d ticks", r.player+1, r.sum, r.stalls)
+		if host.Rollback {
+			fmt.Printf("  %d rollbacks, %d ticks resimulated", r.rollbacks, r.resimulated)
+		}
+		fmt.Println()
+		switch {
+		case r.err != nil:
+			fmt.Printf("    %v\n", r.err)
+			ok = false
+		case r.desync != 0:
+			fmt.Printf("    desync at tick %d\n", r.desync)
+			ok = false
+		case r.sum != results[0].sum:
+			ok = false
+		}
+	}
+	if !ok {
+		fmt.Printf("FAIL: instances disagree after %d ticks\n", target)
+		return 1
+	}
+	fmt.Printf("ok: all instances agree after %d ticks\n", target)
+	return 0
+}
+
+// playNetcheck runs one instance of a netcheck match in real time, with a
+// bot pressing random movement and fire buttons, until every instance has
+// confirmed the checksum of the target tick.
+func playNetcheck(ls *netplay.Lockstep, target, seed uint64, finished *atomic.Int32, players int32) netcheckResult {
+	res := netcheckResult{player: ls.Local}
+	defer ls.Close()
+
+	s := sim.NewSim(ls.Seed)
+	s.Difficulty = ls.Difficulty
+	s.Adaptive = ls.Adaptive
+	s.Start(ls.Level, ls.Players)
+	var rb *netplay.Rollback
+	if ls.Rollback {
+		rb = netplay.NewRollback(ls, s)
+	}
+	inputs := make([]*system.Input, ls.Players)
+	for i := range inputs {
+		inputs[i] = system.NewInput()
+	}
+
+	bot := rand.New(rand.NewPCG(seed, uint64(ls.Local)))
+	var held system.Button
+	done := false
+	ticker := time.NewTicker(time.Second / config.TickRate)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !done {
+			if tick, sum := ls.LastCheck(); tick >= target {
+				res.sum = sum
+				done = true
+				finished.Add(1)
+			}
+		}
+		if done && finished.Load() == players {
+			break
+		}
+		if err := ls.Err(); err != nil {
+			res.err = err
+			if !done {
+				finished.Add(1)
+			}
+			break
+		}
+
+		if bot.IntN(30) == 0 {
+			held = system.Button(bot.IntN(int(system.ButtonFire) << 1))
+		}
+		if rb != nil {
+			if !rb.Advance(config.TickDuration, held) {
+				res.stalls++
+			}
+			continue
+		}
+		buttons, ok := ls.Advance(held)
+		if !ok {
+			res.stalls++
+			continue
+		}
+		for i, in := range inputs {
+			in.Update(buttons[i])
+		}
+		s.Update(config.TickDuration, inputs)
+		if s.Tick%replay.CheckInterval == 0 {
+			ls.Check(s.Tick, s.Checksum())
+		}
+	}
+
+	res.desync = ls.DesyncTick
+	if rb != nil {
+		res.rollbacks, res.resimulated = rb.Rollbacks, rb.Resimulated
+	}
+	return res
+}
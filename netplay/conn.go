@@ -0,0 +1,78 @@
+package netplay
+
+import (
+	"context"
+	"net"
+)
+
+// maxPacket is larger than any packet this package sends.
+const maxPacket = 2048
+
+// packet is a datagram received from addr.
+type packet struct {
+	addr *net.UDPAddr
+	data []byte
+}
+
+// conn is a UDP socket whose reads happen on a background goroutine, so
+// the game loop can drain received packets without blocking.
+type conn struct {
+	udp *net.UDPConn
+	in  chan packet
+}
+
+func listen(addr string) (*conn, error) {
+	lc := net.ListenConfig{Control: allowBroadcast}
+	pc, err := lc.ListenPacket(context.Background(), "udp4", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{udp: pc.(*net.UDPConn), in: make(chan packet, 256)}
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *conn) readLoop() {
+	buf := make([]byte, maxPacket)
+	for {
+		n, addr, err := c.udp.ReadFromUDP(buf)
+		if err != nil {
+			close(c.in)
+			return
+		}
+		p := packet{addr: addr, data: append([]byte(nil), buf[:n]...)}
+		select {
+		case c.in <- p:
+		default: // drop when the game falls behind; senders resend
+		}
+	}
+}
+
+// recv returns the next received packet without blocking.
+func (c *conn) recv() (packet, bool) {
+	select {
+	case p, ok := <-c.in:
+		return p, ok
+	default:
+		return packet{}, false
+	}
+}
+
+// send writes a packet to addr. Errors are ignored: UDP delivery is
+// unreliable anyway and every message that matters is resent.
+func (c *conn) send(addr *net.UDPAddr, w *writer) {
+	c.udp.WriteToUDP(w.buf, addr)
+}
+
+func (c *conn) localAddr() *net.UDPAddr {
+	return c.udp.LocalAddr().(*net.UDPAddr)
+}
+
+func (c *conn) close() {
+	c.udp.Close()
+}
+
+// sameAddr reports whether a and b are the same UDP endpoint.
+func sameAddr(a, b *net.UDPAddr) bool {
+	return a.Port == b.Port && a.IP.Equal(b.IP)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package netplay
+
+import "syscall"
+
+// allowBroadcast is a no-op on other platforms, where discovery may only
+// find hosts on this machine; --join still reaches any address.
+func allowBroadcast(network, address string, c syscall.RawConn) error {
+	return nil
+}
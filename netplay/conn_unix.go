@@ -0,0 +1,18 @@
+//go:build unix
+
+package netplay
+
+import "syscall"
+
+// allowBroadcast lets the socket send to the broadcast address, which
+// discovery uses to find hosts on the LAN.
+func allowBroadcast(network, address string, c syscall.RawConn) error {
+	var err error
+	cerr := c.Control(func(fd uintptr) {
+		err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1)
+	})
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,433 @@
+package netplay
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+)
+
+const (
+	DefaultPort  = 7777 // UDP port hosts listen on
+	DefaultDelay = 4    // ticks between sampling input and simulating it
+	MaxDelay     = 30
+	maxWatchers  = 4 // spectators a lobby admits on top of its players
+
+	lobbyInterval = time.Second     // how often clients announce themselves
+	timeout       = 5 * time.Second // silence after which a peer is gone
+)
+
+// HostInfo describes a host found by discovery.
+type HostInfo struct {
+	Addr    *net.UDPAddr
+	Version string // game version the host runs
+	Players int    // players in the host's lobby, including the host
+	id      uint64
+	seen    time.Time
+}
+
+// peer is a client, or spectator, waiting in a host's lobby.
+type peer struct {
+	addr      *net.UDPAddr
+	lastHeard time.Time
+}
+
+// Host is the lobby of a match this instance hosts. The host is always
+// player 0; clients are numbered in the order they joined.
+type Host struct {
+	Version    string
+	Delay      int               // input delay the match will use, in ticks
+	Rollback   bool              // run the match with rollback instead of plain lockstep
+	Difficulty config.Difficulty // balance preset of the match
+	Adaptive   bool              // run the match with the director enabled
+	id         uint64            // tells clients that reach the host by several addresses it is one host
+	conn       *conn
+	peers      []*peer
+	watchers   []*peer
+}
+
+// NewHost opens a lobby on the given UDP port. Only clients running the
+// same game version may join.
+func NewHost(port int, version string) (*Host, error) {
+	c, err := listen(fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	return &Host{
+		Version:    version,
+		Delay:      DefaultDelay,
+		Difficulty: config.DifficultyNormal,
+		id:         rand.Uint64(),
+		conn:       c,
+	}, nil
+}
+
+// Players returns the number of players in the lobby, including the host.
+func (h *Host) Players() int {
+	return 1 + len(h.peers)
+}
+
+// Watchers returns the number of spectators in the lobby.
+func (h *Host) Watchers() int {
+	return len(h.watchers)
+}
+
+// Peers returns the addresses of the joined clients; Peers()[i] is player i+1.
+func (h *Host) Peers() []*net.UDPAddr {
+	addrs := make([]*net.UDPAddr, len(h.peers))
+	for i, p := range h.peers {
+		addrs[i] = p.addr
+	}
+	return addrs
+}
+
+// Port returns the UDP port the lobby listens on.
+func (h *Host) Port() int {
+	return h.conn.localAddr().Port
+}
+
+// Poll answers discovery requests, admits joining clients and drops
+// clients that have gone quiet. Call it once per frame while in the lobby.
+func (h *Host) Poll() {
+	for {
+		p, ok := h.conn.recv()
+		if !ok {
+			break
+		}
+		t, r, ok := parsePacket(p.data)
+		if !ok {
+			continue
+		}
+		switch t {
+		case msgDiscover:
+			w := newPacket(msgHostInfo)
+			w.uvarint(protocolVersion)
+			w.string(h.Version)
+			w.uvarint(uint64(h.Players()))
+			w.uint64(h.id)
+			h.conn.send(p.addr, w)
+		case msgJoin:
+			proto, version := r.int(), r.string()
+			if r.err == nil {
+				h.admit(p.addr, proto, version)
+			}
+		case msgWatch:
+			proto, version := r.int(), r.string()
+			if r.err == nil {
+				h.admitWatcher(p.addr, proto, version)
+			}
+		case msgLeave:
+			h.remove(p.addr)
+		}
+	}
+
+	now := time.Now()
+	for _, p := range h.peers {
+		if now.Sub(p.lastHeard) > timeout {
+			h.remove(p.addr)
+			break // remove renumbers the rest; catch others next poll
+		}
+	}
+	for _, p := range h.watchers {
+		if now.Sub(p.lastHeard) > timeout {
+			h.remove(p.addr)
+			break
+		}
+	}
+}
+
+func (h *Host) admit(addr *net.UDPAddr, proto int, version string) {
+	i := h.find(addr)
+	switch {
+	case proto != protocolVersion || version != h.Version:
+		h.reject(addr, fmt.Sprintf("HOST RUNS VERSION %s", h.Version))
+	case i < 0 && h.Players() >= config.MaxPlayers:
+		h.reject(addr, "GAME IS FULL")
+	case i < 0:
+		h.peers = append(h.peers, &peer{addr: addr, lastHeard: time.Now()})
+		h.welcomeAll()
+	default:
+		h.peers[i].lastHeard = time.Now()
+		h.welcome(i)
+	}
+}
+
+// admitWatcher handles a spectator's request to watch the match.
+// Spectators are welcomed as player 0, which no client can be.
+func (h *Host) admitWatcher(addr *net.UDPAddr, proto int, version string) {
+	i := findPeer(h.watchers, addr)
+	switch {
+	case proto != protocolVersion || version != h.Version:
+		h.reject(addr, fmt.Sprintf("HOST RUNS VERSION %s", h.Version))
+	case findPeer(h.peers, addr) >= 0:
+		h.reject(addr, "ALREADY PLAYING")
+	case i < 0 && len(h.watchers) >= maxWatchers:
+		h.reject(addr, "TOO MANY SPECTATORS")
+	case i < 0:
+		h.watchers = append(h.watchers, &peer{addr: addr, lastHeard: time.Now()})
+		h.sendWelcome(addr, 0)
+	default:
+		h.watchers[i].lastHeard = time.Now()
+		h.sendWelcome(addr, 0)
+	}
+}
+
+func (h *Host) find(addr *net.UDPAddr) int {
+	return findPeer(h.peers, addr)
+}
+
+func findPeer(peers []*peer, addr *net.UDPAddr) int {
+	for i, p := range peers {
+		if sameAddr(p.addr, addr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *Host) remove(addr *net.UDPAddr) {
+	if i := h.find(addr); i >= 0 {
+		h.peers = append(h.peers[:i], h.peers[i+1:]...)
+		h.welcomeAll()
+	}
+	if i := findPeer(h.watchers, addr); i >= 0 {
+		h.watchers = append(h.watchers[:i], h.watchers[i+1:]...)
+	}
+}
+
+func (h *Host) reject(addr *net.UDPAddr, reason string) {
+	w := newPacket(msgReject)
+	w.string(reason)
+	h.conn.send(addr, w)
+}
+
+// welcomeAll tells every client and spectator its player number and the
+// lobby size.
+func (h *Host) welcomeAll() {
+	for i := range h.peers {
+		h.welcome(i)
+	}
+	for _, p := range h.watchers {
+		h.sendWelcome(p.addr, 0)
+	}
+}
+
+func (h *Host) welcome(i int) {
+	h.sendWelcome(h.peers[i].addr, i+1)
+}
+
+func (h *Host) sendWelcome(addr *net.UDPAddr, index int) {
+	w := newPacket(msgWelcome)
+	w.uvarint(uint64(index))
+	w.uvarint(uint64(h.Players()))
+	h.conn.send(addr, w)
+}
+
+// Start closes the lobby and returns the lockstep session for a match
+// with every joined client, relaying it to every spectator. The host's
+// socket now belongs to the session.
+func (h *Host) Start(seed uint64, level int) *Lockstep {
+	links := make([]*link, len(h.peers), len(h.peers)+len(h.watchers))
+	for i, p := range h.peers {
+		links[i] = newLink(p.addr, i+1)
+	}
+	for _, p := range h.watchers {
+		links = append(links, newLink(p.addr, -1))
+	}
+	ls := newLockstep(h.conn, links, true, 0, h.Players(), h.Delay, seed, level, h.Rollback)
+	ls.Difficulty = h.Difficulty
+	ls.Adaptive = h.Adaptive
+	h.conn = nil
+	return ls
+}
+
+// Close shuts the lobby down, telling joined clients and spectators.
+func (h *Host) Close() {
+	if h.conn == nil {
+		return
+	}
+	for _, p := range h.peers {
+		h.conn.send(p.addr, newPacket(msgLeave))
+	}
+	for _, p := range h.watchers {
+		h.conn.send(p.addr, newPacket(msgLeave))
+	}
+	h.conn.close()
+	h.conn = nil
+}
+
+// Client looks for hosts on the LAN, joins one and waits in its lobby
+// until the host starts the match.
+type Client struct {
+	Version  string
+	Port     int        // port hosts listen on, for discovery
+	Hosts    []HostInfo // hosts currently answering discovery
+	Joined   *net.UDPAddr
+	Watching bool   // Joined was asked for a place as a spectator
+	Index    int    // player number assigned by the host, once welcomed; 0 for a spectator
+	Players  int    // players in the joined lobby
+	Rejected string // why the last join attempt was refused
+	conn     *conn
+	lastSent time.Time
+}
+
+// NewClient opens a socket for discovering and joining hosts that listen
+// on port.
+func NewClient(port int, version string) (*Client, error) {
+	c, err := listen(":0")
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Version: version, Port: port, conn: c}, nil
+}
+
+// Join asks the host at addr ("host:port") for a place in its match.
+func (c *Client) Join(addr string) error {
+	a, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return err
+	}
+	c.Joined = a
+	c.Watching = false
+	c.Index = 0
+	c.Rejected = ""
+	c.sendJoin()
+	return nil
+}
+
+// Watch asks the host at addr ("host:port") to let this client watch its
+// match as a spectator. The session Poll returns then has a Local of -1.
+func (c *Client) Watch(addr string) error {
+	a, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return err
+	}
+	c.Joined = a
+	c.Watching = true
+	c.Index = 0
+	c.Rejected = ""
+	c.sendJoin()
+	return nil
+}
+
+func (c *Client) sendJoin() {
+	t := msgJoin
+	if c.Watching {
+		t = msgWatch
+	}
+	w := newPacket(t)
+	w.uvarint(protocolVersion)
+	w.string(c.Version)
+	c.conn.send(c.Joined, w)
+}
+
+// discover asks every host on the LAN, and on this machine, to identify
+// itself.
+func (c *Client) discover() {
+	w := newPacket(msgDiscover)
+	w.uvarint(protocolVersion)
+	w.string(c.Version)
+	c.conn.send(&net.UDPAddr{IP: net.IPv4bcast, Port: c.Port}, w)
+	c.conn.send(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: c.Port}, w)
+}
+
+// Poll handles lobby traffic and returns the lockstep session once the
+// host starts the match, after which the client's socket belongs to the
+// session. Call it once per frame while in the lobby.
+func (c *Client) Poll() *Lockstep {
+	now := time.Now()
+	if now.Sub(c.lastSent) >= lobbyInterval {
+		c.lastSent = now
+		if c.Joined != nil {
+			c.sendJoin()
+		} else {
+			c.discover()
+		}
+	}
+
+	for {
+		p, ok := c.conn.recv()
+		if !ok {
+			break
+		}
+		t, r, ok := parsePacket(p.data)
+		if !ok {
+			continue
+		}
+		fromHost := c.Joined != nil && sameAddr(p.addr, c.Joined)
+		switch {
+		case t == msgHostInfo:
+			proto, version, players, id := r.int(), r.string(), r.int(), r.uint64()
+			if r.err == nil && proto == protocolVersion {
+				c.found(HostInfo{Addr: p.addr, Version: version, Players: players, id: id, seen: now})
+			}
+		case t == msgWelcome && fromHost:
+			index, players := r.int(), r.int()
+			if r.err == nil {
+				c.Index, c.Players = index, players
+			}
+		case t == msgReject && fromHost:
+			c.Rejected = r.string()
+			c.Joined = nil
+		case t == msgLeave && fromHost:
+			c.Rejected = "HOST CLOSED THE GAME"
+			c.Joined = nil
+		case t == msgStart && fromHost:
+			index, players, delay := r.int(), r.int(), r.int()
+			seed, level, rollback := r.uint64(), r.int(), r.bool()
+			difficulty, adaptive := config.Difficulty(r.int()), r.bool()
+			if r.err != nil || players < 2 || players > config.MaxPlayers || delay < 0 || delay > MaxDelay ||
+				difficulty < 0 || difficulty >= config.DifficultyCount {
+				continue
+			}
+			switch {
+			case c.Watching && index == players:
+				index = -1
+			case c.Watching || index < 1 || index >= players:
+				continue
+			}
+			ls := newLockstep(c.conn, []*link{newLink(c.Joined, 0)}, false, index, players, delay, seed, level, rollback)
+			ls.Difficulty = difficulty
+			ls.Adaptive = adaptive
+			c.conn = nil
+			return ls
+		}
+	}
+
+	n := 0
+	for _, h := range c.Hosts {
+		if now.Sub(h.seen) < 3*lobbyInterval {
+			c.Hosts[n] = h
+			n++
+		}
+	}
+	c.Hosts = c.Hosts[:n]
+	return nil
+}
+
+// found records a discovery answer. A host answering both the broadcast
+// and the loopback query is listed once, under the address seen first.
+func (c *Client) found(info HostInfo) {
+	for i, h := range c.Hosts {
+		if h.id == info.id {
+			info.Addr = h.Addr
+			c.Hosts[i] = info
+			return
+		}
+	}
+	c.Hosts = append(c.Hosts, info)
+}
+
+// Close leaves the lobby.
+func (c *Client) Close() {
+	if c.conn == nil {
+		return
+	}
+	if c.Joined != nil {
+		c.conn.send(c.Joined, newPacket(msgLeave))
+	}
+	c.conn.close()
+	c.conn = nil
+}
@@ -0,0 +1,89 @@
+package netplay
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+func TestSpectator(t *testing.T) {
+	host, err := NewHost(0, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer host.Close()
+	addr := fmt.Sprintf("127.0.0.1:%d", host.Port())
+	player, err := NewClient(0, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer player.Close()
+	watcher, err := NewClient(0, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if err := player.Join(addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Watch(addr); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for host.Players() < 2 || host.Watchers() < 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("lobby has %d players and %d spectators, want 2 and 1", host.Players(), host.Watchers())
+		}
+		host.Poll()
+		player.Poll()
+		watcher.Poll()
+		time.Sleep(time.Millisecond)
+	}
+
+	sessions := []*Lockstep{host.Start(1, 0), nil, nil}
+	for _, ls := range sessions {
+		if ls != nil {
+			defer ls.Close()
+		}
+	}
+	var played, watched [][]system.Button
+	for len(watched) < 100 {
+		if time.Now().After(deadline) {
+			t.Fatalf("spectator saw %d ticks, want 100", len(watched))
+		}
+		if sessions[1] == nil {
+			sessions[1] = player.Poll()
+		}
+		if sessions[2] == nil {
+			sessions[2] = watcher.Poll()
+		}
+		for i, ls := range sessions[:2] {
+			if ls == nil {
+				continue
+			}
+			b, ok := ls.Advance(system.Button(len(played)+i) & system.ButtonFire)
+			if ok && i == 0 {
+				played = append(played, b)
+			}
+		}
+		if ls := sessions[2]; ls != nil {
+			if b, ok := ls.Advance(system.ButtonFire); ok {
+				watched = append(watched, b)
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if ls := sessions[2]; ls.Local != -1 || ls.Players != 2 {
+		t.Errorf("spectator session has Local %d and Players %d, want -1 and 2", ls.Local, ls.Players)
+	}
+	for tick, b := range watched {
+		if !slices.Equal(b, played[tick]) {
+			t.Fatalf("tick %d: spectator saw %v, host played %v", tick, b, played[tick])
+		}
+	}
+}
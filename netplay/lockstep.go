@@ -0,0 +1,388 @@
+package netplay
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+// maxWindow caps how many ticks of one player's input a packet carries.
+const maxWindow = 128
+
+// hashHistory is how many ticks of checksums are kept for comparison.
+const hashHistory = 60 * 120
+
+// link is the session's view of one remote instance.
+type link struct {
+	addr      *net.UDPAddr
+	player    int   // player index controlled at addr, or -1 for a spectator
+	acked     []int // acked[p] is how many ticks of player p's input addr has
+	lastHeard time.Time
+	started   bool // addr has sent input, so it has seen the start message
+}
+
+func newLink(addr *net.UDPAddr, player int) *link {
+	return &link{addr: addr, player: player, lastHeard: time.Now()}
+}
+
+// Lockstep is a networked match in progress. Every instance feeds its
+// local player's buttons into Advance once per simulation tick; Advance returns
+// the buttons of every player once they are all known for the next tick.
+// Local input is scheduled Delay ticks ahead so that it has time to reach
+// the other instances before it is needed. A spectator's session has no
+// local player and ignores the buttons passed to Advance.
+type Lockstep struct {
+	Local   int    // index of the local player, or -1 when spectating
+	Players int    // players in the match
+	Delay   int    // input delay in ticks
+	Seed    uint64 // simulation seed chosen by the host
+	Level   int    // level the match starts on
+	Tick    uint64 // next tick to simulate
+
+	// Difficulty is the balance preset chosen by the host, and Adaptive
+	// whether the host enabled the director.
+	Difficulty config.Difficulty
+	Adaptive   bool
+
+	// Rollback is set when the host chose to run the match with a Rollback
+	// session on top of this one rather than in plain lockstep.
+	Rollback bool
+
+	// DesyncTick is the first tick whose checksum differed between two
+	// instances, or 0. Clients learn of desyncs between the host and other
+	// clients from the host.
+	DesyncTick uint64
+
+	conn   *conn
+	links  []*link // the host links to every client; clients link to the host
+	host   bool
+	inputs [][]system.Button // inputs[p][t] is player p's buttons on tick t
+
+	localHashes  map[uint64]uint64
+	remoteHashes map[uint64]uint64
+	lastCheck    uint64 // tick of the latest local checksum
+	lastSum      uint64
+
+	stalledSince time.Time
+	err          error
+}
+
+func newLockstep(c *conn, links []*link, host bool, local, players, delay int, seed uint64, level int, rollback bool) *Lockstep {
+	l := &Lockstep{
+		Local:        local,
+		Players:      players,
+		Delay:        delay,
+		Seed:         seed,
+		Level:        level,
+		Rollback:     rollback,
+		conn:         c,
+		links:        links,
+		host:         host,
+		inputs:       make([][]system.Button, players),
+		localHashes:  make(map[uint64]uint64),
+		remoteHashes: make(map[uint64]uint64),
+	}
+	// Nobody has input for the first Delay ticks; everyone agrees it is idle.
+	for p := range l.inputs {
+		l.inputs[p] = make([]system.Button, delay)
+	}
+	for _, k := range links {
+		k.acked = make([]int, players)
+		for p := range k.acked {
+			k.acked[p] = delay
+		}
+	}
+	return l
+}
+
+// Advance schedules the local player's buttons, exchanges input with the
+// other instances and, if every player's input for the next tick is known,
+// returns it and advances. It returns false while waiting on the network.
+func (l *Lockstep) Advance(local system.Button) ([]system.Button, bool) {
+	t := int(l.Tick)
+	l.exchange(t, local)
+	if !l.complete(t) {
+		l.setStalled(true)
+		return nil, false
+	}
+	l.setStalled(false)
+
+	out := make([]system.Button, l.Players)
+	for p := range out {
+		out[p] = l.inputs[p][t]
+	}
+	l.Tick++
+	return out, true
+}
+
+// exchange schedules the local player's buttons for tick t+Delay, unless
+// they already are, and trades input with the other instances.
+func (l *Lockstep) exchange(t int, local system.Button) {
+	l.poll()
+	if l.Local >= 0 && len(l.inputs[l.Local]) <= t+l.Delay {
+		l.inputs[l.Local] = append(l.inputs[l.Local], local)
+	}
+	l.send()
+}
+
+// complete reports whether every player's input for tick t is known.
+func (l *Lockstep) complete(t int) bool {
+	for p := range l.inputs {
+		if len(l.inputs[p]) <= t {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Lockstep) setStalled(stalled bool) {
+	switch {
+	case !stalled:
+		l.stalledSince = time.Time{}
+	case l.stalledSince.IsZero():
+		l.stalledSince = time.Now()
+	}
+}
+
+// Check records the checksum of the state after tick and compares it with
+// the remote instances' checksums for the same tick.
+func (l *Lockstep) Check(tick, sum uint64) {
+	l.localHashes[tick] = sum
+	l.lastCheck, l.lastSum = tick, sum
+	if remote, ok := l.remoteHashes[tick]; ok {
+		l.compare(tick, sum, remote)
+	}
+	for t := range l.localHashes {
+		if t+hashHistory < tick {
+			delete(l.localHashes, t)
+		}
+	}
+	for t := range l.remoteHashes {
+		if t+hashHistory < tick {
+			delete(l.remoteHashes, t)
+		}
+	}
+}
+
+// LastCheck returns the latest tick and checksum passed to Check.
+func (l *Lockstep) LastCheck() (tick, sum uint64) {
+	return l.lastCheck, l.lastSum
+}
+
+func (l *Lockstep) compare(tick, local, remote uint64) {
+	if local != remote && l.DesyncTick == 0 {
+		l.DesyncTick = tick
+	}
+}
+
+// Stalled returns how long the session has been waiting for remote input,
+// or 0 if it is not waiting.
+func (l *Lockstep) Stalled() time.Duration {
+	if l.stalledSince.IsZero() {
+		return 0
+	}
+	return time.Since(l.stalledSince)
+}
+
+// Err returns the reason the session can no longer continue, if any.
+func (l *Lockstep) Err() error {
+	return l.err
+}
+
+// Close leaves the match, telling the other instances.
+func (l *Lockstep) Close() {
+	if l.conn == nil {
+		return
+	}
+	for _, k := range l.links {
+		l.conn.send(k.addr, newPacket(msgLeave))
+	}
+	l.conn.close()
+	l.conn = nil
+}
+
+func (l *Lockstep) findLink(addr *net.UDPAddr) *link {
+	for _, k := range l.links {
+		if sameAddr(k.addr, addr) {
+			return k
+		}
+	}
+	return nil
+}
+
+func (l *Lockstep) poll() {
+	for {
+		p, ok := l.conn.recv()
+		if !ok {
+			break
+		}
+		t, r, ok := parsePacket(p.data)
+		k := l.findLink(p.addr)
+		if !ok || k == nil {
+			continue
+		}
+		switch t {
+		case msgInput:
+			l.receive(k, r)
+		case msgLeave:
+			if k.player < 0 {
+				l.dropLink(k)
+			} else if l.err == nil {
+				l.err = fmt.Errorf("netplay: %dP left the match", k.player+1)
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, k := range l.links {
+		if now.Sub(k.lastHeard) <= timeout {
+			continue
+		}
+		if k.player < 0 {
+			l.dropLink(k)
+			break // dropLink shortens links; catch others next poll
+		}
+		if l.err == nil {
+			l.err = fmt.Errorf("netplay: lost connection to %dP", k.player+1)
+		}
+	}
+}
+
+// dropLink stops relaying the match to a spectator, who is not needed to
+// carry on.
+func (l *Lockstep) dropLink(k *link) {
+	for i, kk := range l.links {
+		if kk == k {
+			l.links = append(l.links[:i], l.links[i+1:]...)
+			return
+		}
+	}
+}
+
+// An input message carries, in order:
+//
+//	players               number of players
+//	have[players]         ticks of each player's input the sender has
+//	checkTick, checkSum   the sender's latest state checksum (tick 0 = none)
+//	desyncTick            the sender's DesyncTick
+//	entries               count, then per entry:
+//	  player, start, n    n ticks of player's input starting at tick start
+//	  buttons[n]
+func (l *Lockstep) send() {
+	for _, k := range l.links {
+		if l.host && !k.started {
+			index := k.player
+			if index < 0 {
+				index = l.Players // a spectator
+			}
+			w := newPacket(msgStart)
+			w.uvarint(uint64(index))
+			w.uvarint(uint64(l.Players))
+			w.uvarint(uint64(l.Delay))
+			w.uint64(l.Seed)
+			w.uvarint(uint64(l.Level))
+			w.bool(l.Rollback)
+			w.uvarint(uint64(l.Difficulty))
+			w.bool(l.Adaptive)
+			l.conn.send(k.addr, w)
+		}
+
+		w := newPacket(msgInput)
+		w.uvarint(uint64(l.Players))
+		for p := range l.inputs {
+			w.uvarint(uint64(len(l.inputs[p])))
+		}
+		w.uvarint(l.lastCheck)
+		w.uint64(l.lastSum)
+		w.uvarint(l.DesyncTick)
+
+		var entries []int
+		for p := range l.inputs {
+			if p != k.player && k.acked[p] < len(l.inputs[p]) {
+				entries = append(entries, p)
+			}
+		}
+		w.uvarint(uint64(len(entries)))
+		for _, p := range entries {
+			start := k.acked[p]
+			end := min(len(l.inputs[p]), start+maxWindow)
+			w.uvarint(uint64(p))
+			w.uvarint(uint64(start))
+			w.uvarint(uint64(end - start))
+			for _, b := range l.inputs[p][start:end] {
+				w.uvarint(uint64(b))
+			}
+		}
+		l.conn.send(k.addr, w)
+	}
+}
+
+func (l *Lockstep) receive(k *link, r *reader) {
+	if r.int() != l.Players {
+		return
+	}
+	have := make([]int, l.Players)
+	for p := range have {
+		if have[p] = r.int(); have[p] < 0 {
+			return
+		}
+	}
+	checkTick, checkSum, desyncTick := r.uvarint(), r.uint64(), r.uvarint()
+
+	type entry struct {
+		player, start int
+		buttons       []system.Button
+	}
+	var entries []entry
+	n := r.int()
+	if n < 0 || n > l.Players {
+		return
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		e := entry{player: r.int(), start: r.int()}
+		count := r.int()
+		// Values past MaxInt64 come out negative; reject them along with
+		// oversized ones.
+		if e.player < 0 || e.player >= l.Players || e.start < 0 || count < 0 || count > maxWindow {
+			return
+		}
+		e.buttons = make([]system.Button, count)
+		for j := range e.buttons {
+			e.buttons[j] = system.Button(r.uvarint())
+		}
+		entries = append(entries, e)
+	}
+	if r.err != nil {
+		return
+	}
+
+	k.lastHeard = time.Now()
+	k.started = true
+	for p, h := range have {
+		k.acked[p] = max(k.acked[p], h)
+	}
+	for _, e := range entries {
+		// The host only accepts a client's own input; clients accept
+		// everything the host relays.
+		if l.host && e.player != k.player {
+			continue
+		}
+		got := len(l.inputs[e.player])
+		if e.start <= got && e.start+len(e.buttons) > got {
+			l.inputs[e.player] = append(l.inputs[e.player], e.buttons[got-e.start:]...)
+		}
+	}
+	if l.DesyncTick == 0 {
+		l.DesyncTick = desyncTick
+	}
+	if checkTick != 0 {
+		if local, ok := l.localHashes[checkTick]; ok {
+			l.compare(checkTick, local, checkSum)
+		} else {
+			l.remoteHashes[checkTick] = checkSum
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package netplay
+
+import (
+	"math"
+	"testing"
+)
+
+// inputPacket encodes an input message for a two-player match holding one
+// entry for player with count buttons from start.
+func inputPacket(player, start, count uint64) []byte {
+	var w writer
+	w.uvarint(2) // players
+	w.uvarint(0) // have, per player
+	w.uvarint(0)
+	w.uvarint(0) // check tick
+	w.uint64(0)  // check sum
+	w.uvarint(0) // desync tick
+	w.uvarint(1) // entries
+	w.uvarint(player)
+	w.uvarint(start)
+	w.uvarint(count)
+	for i := uint64(0); i < count && i < 4; i++ {
+		w.uvarint(1)
+	}
+	return w.buf
+}
+
+func newTestLockstep() (*Lockstep, *link) {
+	k := newLink(nil, 1)
+	return newLockstep(nil, []*link{k}, true, 0, 2, 2, 1, 0, false), k
+}
+
+func TestLockstepReceive(t *testing.T) {
+	huge := uint64(math.MaxInt64) + 2 // negative as an int
+	valid := inputPacket(1, 2, 3)
+	tests := []struct {
+		name   string
+		packet []byte
+		want   int // inputs held for player 1 afterwards
+	}{
+		{"valid", valid, 5},
+		{"player out of range", inputPacket(2, 2, 3), 2},
+		{"negative player", inputPacket(huge, 2, 3), 2},
+		{"negative start", inputPacket(1, huge, 3), 2},
+		{"negative count", inputPacket(1, 2, huge), 2},
+		{"oversized count", inputPacket(1, 2, maxWindow+1), 2},
+	}
+	for n := 0; n < len(valid); n++ {
+		tests = append(tests, struct {
+			name   string
+			packet []byte
+			want   int
+		}{"truncated", valid[:n], 2})
+	}
+	for _, tc := range tests {
+		l, k := newTestLockstep()
+		l.receive(k, &reader{buf: tc.packet})
+		if got := len(l.inputs[1]); got != tc.want {
+			t.Errorf("%s (%d bytes): player 1 has %d inputs, want %d", tc.name, len(tc.packet), got, tc.want)
+		}
+	}
+}
+
+func TestLockstepReceiveNegativeEntryCount(t *testing.T) {
+	var w writer
+	w.uvarint(2)
+	w.uvarint(0)
+	w.uvarint(0)
+	w.uvarint(0)
+	w.uint64(0)
+	w.uvarint(0)
+	w.uvarint(uint64(math.MaxInt64) + 1)
+	l, k := newTestLockstep()
+	l.receive(k, &reader{buf: w.buf})
+	if k.started {
+		t.Error("accepted a packet with a negative entry count")
+	}
+}
+
+func FuzzLockstepReceive(f *testing.F) {
+	f.Add(inputPacket(1, 2, 3))
+	f.Add(inputPacket(0, 0, 1))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		l, k := newTestLockstep()
+		l.receive(k, &reader{buf: data})
+	})
+}
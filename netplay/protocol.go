@@ -0,0 +1,140 @@
+// Package netplay runs matches between TankStrike instances on a LAN. A
+// host accepts up to config.MaxPlayers-1 clients in a lobby, then every
+// instance simulates the same match, either in lockstep, where no tick is
+// run until the input of every player for that tick has arrived, or with
+// rollback, where missing input is predicted and mistakes are corrected by
+// re-simulating. Periodic state checksums are exchanged to detect desyncs.
+//
+// Spectators can join a lobby as well, up to maxWatchers of them. They
+// simulate the match from every player's input without adding any, and
+// the match does not wait for them.
+//
+// Everything travels over UDP. Clients talk only to the host, which relays
+// each player's input to the others. Input is resent until acknowledged,
+// so lost packets cost latency rather than correctness.
+package netplay
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Packets start with a four byte header, "TSN" and a message type,
+// followed by a payload of unsigned varints, little-endian uint64s and
+// length-prefixed strings.
+const magic = "TSN"
+
+// protocolVersion changes whenever the packet layout does.
+const protocolVersion = 4
+
+type msgType byte
+
+const (
+	msgDiscover msgType = iota + 1 // client to anyone: protocol, version
+	msgHostInfo                    // host to client: protocol, version, players, host id
+	msgJoin                        // client to host: protocol, version
+	msgWelcome                     // host to client: player index, players
+	msgReject                      // host to client: reason
+	msgStart                       // host to client: player index (players for a spectator), players, delay, seed, level, rollback, difficulty, adaptive
+	msgInput                       // either way: see Lockstep.send
+	msgLeave                       // either way: the sender is quitting
+	msgWatch                       // client to host: protocol, version
+)
+
+var errShortPacket = errors.New("netplay: short packet")
+
+// writer builds a packet.
+type writer struct {
+	buf []byte
+}
+
+func newPacket(t msgType) *writer {
+	w := &writer{buf: make([]byte, 0, 256)}
+	w.buf = append(w.buf, magic...)
+	w.buf = append(w.buf, byte(t))
+	return w
+}
+
+func (w *writer) uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *writer) uint64(v uint64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, v)
+}
+
+func (w *writer) bool(b bool) {
+	if b {
+		w.uvarint(1)
+	} else {
+		w.uvarint(0)
+	}
+}
+
+func (w *writer) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// reader parses a packet payload. After the first error every read
+// returns zero, so callers check err once at the end.
+type reader struct {
+	buf []byte
+	err error
+}
+
+// parsePacket checks the header of data and returns its type and payload.
+func parsePacket(data []byte) (msgType, *reader, bool) {
+	if len(data) < len(magic)+1 || string(data[:len(magic)]) != magic {
+		return 0, nil, false
+	}
+	return msgType(data[len(magic)]), &reader{buf: data[len(magic)+1:]}, true
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = errShortPacket
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *reader) int() int {
+	return int(r.uvarint())
+}
+
+func (r *reader) bool() bool {
+	return r.uvarint() != 0
+}
+
+func (r *reader) uint64() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.buf) < 8 {
+		r.err = errShortPacket
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v
+}
+
+func (r *reader) string() string {
+	n := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+	if uint64(len(r.buf)) < n {
+		r.err = errShortPacket
+		return ""
+	}
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
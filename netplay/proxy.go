@@ -0,0 +1,98 @@
+package netplay
+
+import (
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// Proxy relays UDP traffic between one client and a host, delaying and
+// dropping packets in both directions to imitate a poor network. The
+// client joins the proxy's address instead of the host's.
+type Proxy struct {
+	Latency time.Duration // one-way delay added to every packet
+	Jitter  time.Duration // random extra delay of up to this much
+	Loss    float64       // fraction of packets dropped
+
+	host   *net.UDPAddr
+	front  *net.UDPConn // faces the client
+	back   *net.UDPConn // faces the host, which sees it as the client
+	mu     sync.Mutex
+	rng    *rand.Rand
+	client *net.UDPAddr // last address the client sent from
+}
+
+// NewProxy starts relaying between a client and the host at hostAddr
+// ("host:port"). Random delays and drops are drawn from seed.
+func NewProxy(hostAddr string, latency, jitter time.Duration, loss float64, seed uint64) (*Proxy, error) {
+	host, err := net.ResolveUDPAddr("udp4", hostAddr)
+	if err != nil {
+		return nil, err
+	}
+	loopback := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	front, err := net.ListenUDP("udp4", loopback)
+	if err != nil {
+		return nil, err
+	}
+	back, err := net.ListenUDP("udp4", loopback)
+	if err != nil {
+		front.Close()
+		return nil, err
+	}
+	p := &Proxy{
+		Latency: latency,
+		Jitter:  jitter,
+		Loss:    loss,
+		host:    host,
+		front:   front,
+		back:    back,
+		rng:     rand.New(rand.NewPCG(seed, 0)),
+	}
+	go p.relay(front, back, func(from *net.UDPAddr) *net.UDPAddr {
+		p.client = from
+		return p.host
+	})
+	go p.relay(back, front, func(*net.UDPAddr) *net.UDPAddr {
+		return p.client
+	})
+	return p, nil
+}
+
+// Addr returns the address clients should join.
+func (p *Proxy) Addr() string {
+	return p.front.LocalAddr().String()
+}
+
+// relay forwards packets read from in out of out, to the address dest
+// picks for each.
+func (p *Proxy) relay(in, out *net.UDPConn, dest func(from *net.UDPAddr) *net.UDPAddr) {
+	buf := make([]byte, maxPacket)
+	for {
+		n, from, err := in.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		data := append([]byte(nil), buf[:n]...)
+
+		p.mu.Lock()
+		to := dest(from)
+		drop := p.rng.Float64() < p.Loss
+		delay := p.Latency
+		if p.Jitter > 0 {
+			delay += time.Duration(p.rng.Int64N(int64(p.Jitter)))
+		}
+		p.mu.Unlock()
+
+		if drop || to == nil {
+			continue
+		}
+		time.AfterFunc(delay, func() { out.WriteToUDP(data, to) })
+	}
+}
+
+// Close stops relaying.
+func (p *Proxy) Close() {
+	p.front.Close()
+	p.back.Close()
+}
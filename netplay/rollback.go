@@ -0,0 +1,168 @@
+package netplay
+
+import (
+	"github.com/AchrafSoltani/TankStrike/replay"
+	"github.com/AchrafSoltani/TankStrike/sim"
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+// DefaultMaxPrediction is how many ticks a rollback session may simulate
+// past the last tick whose input is fully known.
+const DefaultMaxPrediction = 12
+
+// frame is what a rollback session remembers about one simulated tick.
+type frame struct {
+	snap    sim.Snapshot    // state before the tick
+	held    []system.Button // every player's buttons on the previous tick
+	buttons []system.Button // buttons the tick was simulated with
+}
+
+// Rollback runs a networked match without waiting for remote input. Input
+// that has not arrived is predicted by repeating the player's last known
+// buttons; when the real input turns up and differs, the match is restored
+// to the first mispredicted tick and simulated forward again. Input is
+// exchanged by the underlying Lockstep session, whose own Advance is not
+// used.
+//
+// Only the latest tick's events are left in Sim.Events: effects of
+// mispredicted ticks have already been presented, and those of corrected
+// ticks are not presented again.
+type Rollback struct {
+	Net *Lockstep
+	Sim *sim.Sim
+
+	// MaxPrediction is how far the match may run ahead of known input
+	// before it waits, in ticks. Change it only before the first Advance.
+	MaxPrediction int
+
+	Rollbacks   int // times the match was rolled back
+	Resimulated int // ticks simulated again after a rollback
+
+	inputs    []*system.Input
+	frames    []frame           // ring buffer indexed by tick
+	confirmed int               // ticks simulated with every player's real input
+	pending   map[uint64]uint64 // checksums of ticks not confirmed yet
+}
+
+// NewRollback wraps a lockstep session in a rollback session driving s,
+// which must just have been started with the session's seed, level and
+// player count.
+func NewRollback(net *Lockstep, s *sim.Sim) *Rollback {
+	r := &Rollback{
+		Net:           net,
+		Sim:           s,
+		MaxPrediction: DefaultMaxPrediction,
+		inputs:        make([]*system.Input, net.Players),
+		pending:       make(map[uint64]uint64),
+	}
+	for i := range r.inputs {
+		r.inputs[i] = system.NewInput()
+	}
+	return r
+}
+
+// Inputs returns the input every player had on the latest simulated tick,
+// predicted or not.
+func (r *Rollback) Inputs() []*system.Input {
+	return r.inputs
+}
+
+// Confirmed returns how many ticks have been simulated with every
+// player's real input.
+func (r *Rollback) Confirmed() int {
+	return r.confirmed
+}
+
+// Advance exchanges input with the other instances, rolls back and
+// re-simulates if earlier predictions turned out wrong, then simulates one
+// more tick of dt seconds. It returns false, without simulating, if that
+// tick would run too far ahead of the known input.
+func (r *Rollback) Advance(dt float64, local system.Button) bool {
+	if r.frames == nil {
+		r.frames = make([]frame, r.MaxPrediction+1)
+	}
+
+	t := int(r.Sim.Tick)
+	r.Net.exchange(t, local)
+
+	if first := r.mispredicted(t); first >= 0 {
+		r.Rollbacks++
+		f := &r.frames[first%len(r.frames)]
+		r.Sim.Restore(&f.snap)
+		for p, in := range r.inputs {
+			in.Held = f.held[p]
+		}
+		for tt := first; tt < t; tt++ {
+			r.simulate(dt, tt)
+			r.Resimulated++
+		}
+	}
+	r.confirm(t)
+
+	if t-r.confirmed >= r.MaxPrediction {
+		r.Net.setStalled(true)
+		return false
+	}
+	r.Net.setStalled(false)
+	r.simulate(dt, t)
+	return true
+}
+
+// mispredicted returns the first unconfirmed tick before t that was
+// simulated with input that differs from what is now known, or -1.
+func (r *Rollback) mispredicted(t int) int {
+	for tt := r.confirmed; tt < t; tt++ {
+		used := r.frames[tt%len(r.frames)].buttons
+		for p, in := range r.Net.inputs {
+			if tt < len(in) && in[tt] != used[p] {
+				return tt
+			}
+		}
+	}
+	return -1
+}
+
+// confirm moves the confirmed mark past every tick before t whose input
+// is now fully known, reporting checksums of those ticks for desync
+// detection.
+func (r *Rollback) confirm(t int) {
+	for r.confirmed < t && r.Net.complete(r.confirmed) {
+		r.confirmed++
+		tick := uint64(r.confirmed)
+		if sum, ok := r.pending[tick]; ok {
+			r.Net.Check(tick, sum)
+			delete(r.pending, tick)
+		}
+	}
+}
+
+// simulate runs tick t with the best input known for it.
+func (r *Rollback) simulate(dt float64, t int) {
+	f := &r.frames[t%len(r.frames)]
+	r.Sim.Save(&f.snap)
+	f.held = f.held[:0]
+	f.buttons = f.buttons[:0]
+	for p, in := range r.inputs {
+		b := r.predict(p, t)
+		f.held = append(f.held, in.Held)
+		f.buttons = append(f.buttons, b)
+		in.Update(b)
+	}
+	r.Sim.Update(dt, r.inputs)
+	if r.Sim.Tick%replay.CheckInterval == 0 {
+		r.pending[r.Sim.Tick] = r.Sim.Checksum()
+	}
+}
+
+// predict returns player p's buttons on tick t: the real ones if known,
+// otherwise the last ones known.
+func (r *Rollback) predict(p, t int) system.Button {
+	in := r.Net.inputs[p]
+	switch {
+	case t < len(in):
+		return in[t]
+	case len(in) > 0:
+		return in[len(in)-1]
+	}
+	return 0
+}
@@ -5,14 +5,16 @@ import (
 	"github.com/AchrafSoltani/TankStrike/entity"
 )
 
-// DrawBullet draws a bullet with its trail.
-func DrawBullet(canvas *ScaledCanvas, b *entity.Bullet, offsetX, offsetY int) {
+// DrawBullet draws a bullet with its trail, interpolated alpha (0-1) of
+// the way through the current tick.
+func DrawBullet(canvas *ScaledCanvas, b *entity.Bullet, offsetX, offsetY int, alpha float64) {
 	if !b.Active {
 		return
 	}
 
-	px := int(b.X) + offsetX
-	py := int(b.Y) + offsetY
+	x, y := b.Interpolate(alpha)
+	px := int(x) + offsetX
+	py := int(y) + offsetY
 
 	// Trail
 	for i := 0; i < b.TrailCount; i++ {
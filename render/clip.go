@@ -0,0 +1,259 @@
+package render
+
+import (
+	"bytes"
+	"cmp"
+	"image"
+	"image/color"
+	"image/gif"
+	"slices"
+)
+
+// Clip keeps the most recent frames of the game, shrunk and quantised to
+// 256 colours each, in a ring buffer, ready to be written out as an
+// animated GIF. A frame identical to the one before it only lengthens that
+// frame's delay, so a still picture takes one slot however long it lasts.
+//
+// Quantising a frame takes far longer than shrinking it, so Add only
+// shrinks a copy and hands it to a goroutine that quantises frames in the
+// order they were added.
+type Clip struct {
+	queue chan clipJob
+	scale int
+	delay int
+	added int
+
+	// Owned by the goroutine.
+	frames []*image.Paletted
+	delays []int // in hundredths of a second
+	next   int   // slot the next frame goes in
+	count  int
+	last   *image.RGBA // shrunk copy of the newest frame
+}
+
+// clipJob is a frame for the clip's goroutine to add or, if gif is set, a
+// request for the clip so far.
+type clipJob struct {
+	frame *image.RGBA
+	gif   chan<- *gif.GIF
+}
+
+// clipQueue is how many frames may wait to be quantised before Add blocks.
+const clipQueue = 32
+
+// NewClip creates a clip holding the given number of frames, each shrunk
+// by scale and shown for delay hundredths of a second. Close stops it.
+func NewClip(frames, scale, delay int) *Clip {
+	c := &Clip{
+		queue:  make(chan clipJob, clipQueue),
+		scale:  max(1, scale),
+		delay:  delay,
+		frames: make([]*image.Paletted, frames),
+		delays: make([]int, frames),
+	}
+	go c.run()
+	return c
+}
+
+// Len returns how many frames have been added to the clip.
+func (c *Clip) Len() int {
+	return c.added
+}
+
+// Add appends a frame, dropping the oldest once the clip is full. img may
+// be reused as soon as Add returns.
+func (c *Clip) Add(img *image.RGBA) {
+	c.added++
+	c.queue <- clipJob{frame: shrink(img, c.scale)}
+}
+
+// GIF returns a channel that receives the clip, as of every frame added
+// so far, as an animation that loops forever, oldest frame first. The
+// frames are never modified after they are added, so it is safe to encode
+// while the clip carries on recording.
+func (c *Clip) GIF() <-chan *gif.GIF {
+	out := make(chan *gif.GIF, 1)
+	c.queue <- clipJob{gif: out}
+	return out
+}
+
+// Close stops the clip's goroutine once it has handled every frame and
+// request sent before. The clip must not be used afterwards.
+func (c *Clip) Close() {
+	close(c.queue)
+}
+
+func (c *Clip) run() {
+	for job := range c.queue {
+		if job.gif != nil {
+			job.gif <- c.snapshot()
+		} else {
+			c.add(job.frame)
+		}
+	}
+}
+
+func (c *Clip) add(frame *image.RGBA) {
+	if c.count > 0 && bytes.Equal(c.last.Pix, frame.Pix) {
+		last := (c.next + len(c.frames) - 1) % len(c.frames)
+		c.delays[last] += c.delay
+		return
+	}
+	c.last = frame
+	c.frames[c.next] = quantize(frame)
+	c.delays[c.next] = c.delay
+	c.next = (c.next + 1) % len(c.frames)
+	c.count = min(c.count+1, len(c.frames))
+}
+
+func (c *Clip) snapshot() *gif.GIF {
+	g := &gif.GIF{}
+	start := (c.next - c.count + len(c.frames)) % len(c.frames)
+	for i := 0; i < c.count; i++ {
+		j := (start + i) % len(c.frames)
+		g.Image = append(g.Image, c.frames[j])
+		g.Delay = append(g.Delay, c.delays[j])
+	}
+	return g
+}
+
+// shrink returns a copy of img shrunk by scale, keeping one pixel in every
+// scale x scale block so the game's flat colours stay exact.
+func shrink(img *image.RGBA, scale int) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()/scale, b.Dy()/scale))
+	for y := 0; y < out.Rect.Dy(); y++ {
+		for x := 0; x < out.Rect.Dx(); x++ {
+			out.SetRGBA(x, y, img.RGBAAt(b.Min.X+x*scale, b.Min.Y+y*scale))
+		}
+	}
+	return out
+}
+
+// quantize maps img onto a palette of at most 256 colours chosen by
+// median cut.
+func quantize(img *image.RGBA) *image.Paletted {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	hist := make(map[color.RGBA]int)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			hist[img.RGBAAt(x, y)]++
+		}
+	}
+	pal := medianCut(hist, 256)
+
+	index := make(map[color.RGBA]uint8, len(hist))
+	for c := range hist {
+		index[c] = uint8(pal.Index(c))
+	}
+	out := image.NewPaletted(image.Rect(0, 0, w, h), pal)
+	for y := 0; y < h; y++ {
+		row := out.Pix[y*out.Stride:]
+		for x := 0; x < w; x++ {
+			row[x] = index[img.RGBAAt(x, y)]
+		}
+	}
+	return out
+}
+
+// colorCount is one colour of a histogram and how many pixels have it.
+type colorCount struct {
+	c color.RGBA
+	n int
+}
+
+// medianCut picks up to n colours to stand for the histogram's. While
+// there are too many, the box of colours that spans the widest range on
+// one channel is split in two at its median pixel on that channel; each
+// box then contributes its pixel-weighted average.
+func medianCut(hist map[color.RGBA]int, n int) color.Palette {
+	all := make([]colorCount, 0, len(hist))
+	for c, k := range hist {
+		all = append(all, colorCount{c, k})
+	}
+	// Map order is random; sort so the palette is the same every time.
+	slices.SortFunc(all, func(a, b colorCount) int { return cmp.Compare(packRGBA(a.c), packRGBA(b.c)) })
+
+	if len(all) == 0 {
+		return color.Palette{color.Black}
+	}
+
+	boxes := []colorBox{newColorBox(all)}
+	for len(boxes) < n {
+		best := 0
+		for i, box := range boxes {
+			if box.spread > boxes[best].spread {
+				best = i
+			}
+		}
+		box := boxes[best]
+		if box.spread == 0 {
+			break
+		}
+
+		slices.SortStableFunc(box.colors, func(a, b colorCount) int {
+			return cmp.Compare(channel(a.c, box.channel), channel(b.c, box.channel))
+		})
+		total := 0
+		for _, e := range box.colors {
+			total += e.n
+		}
+		split, seen := 1, box.colors[0].n
+		for split < len(box.colors)-1 && seen < total/2 {
+			seen += box.colors[split].n
+			split++
+		}
+		boxes[best] = newColorBox(box.colors[:split])
+		boxes = append(boxes, newColorBox(box.colors[split:]))
+	}
+
+	pal := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		var r, g, b, total int
+		for _, e := range box.colors {
+			r += int(e.c.R) * e.n
+			g += int(e.c.G) * e.n
+			b += int(e.c.B) * e.n
+			total += e.n
+		}
+		pal[i] = color.RGBA{uint8(r / total), uint8(g / total), uint8(b / total), 255}
+	}
+	return pal
+}
+
+// colorBox is a set of colours and the channel they are most spread out
+// on.
+type colorBox struct {
+	colors  []colorCount
+	channel int
+	spread  int
+}
+
+func newColorBox(colors []colorCount) colorBox {
+	box := colorBox{colors: colors}
+	for ch := 0; ch < 3; ch++ {
+		lo, hi := 255, 0
+		for _, e := range colors {
+			v := channel(e.c, ch)
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		if hi-lo > box.spread {
+			box.channel, box.spread = ch, hi-lo
+		}
+	}
+	return box
+}
+
+func channel(c color.RGBA, ch int) int {
+	switch ch {
+	case 0:
+		return int(c.R)
+	case 1:
+		return int(c.G)
+	}
+	return int(c.B)
+}
+
+func packRGBA(c color.RGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+func TestClip(t *testing.T) {
+	c := NewClip(3, 2, 5)
+	defer c.Close()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	fill := func(col color.RGBA) {
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = col.R, col.G, col.B, col.A
+		}
+	}
+	red := color.RGBA{255, 0, 0, 255}
+	colors := []color.RGBA{red, red, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}}
+	for _, col := range colors {
+		fill(col)
+		c.Add(img) // img is reused, as the game loop does
+	}
+
+	anim := <-c.GIF()
+	if c.Len() != len(colors) {
+		t.Errorf("Len is %d, want %d", c.Len(), len(colors))
+	}
+	if want := []int{5, 5, 5}; !slices.Equal(anim.Delay, want) {
+		t.Errorf("delays are %v, want %v", anim.Delay, want)
+	}
+	for i, want := range colors[2:] {
+		frame := anim.Image[i]
+		if frame.Rect != image.Rect(0, 0, 4, 3) {
+			t.Errorf("frame %d is %v, want 4x3", i, frame.Rect)
+		}
+		if got := frame.Palette[frame.Pix[0]]; got != want {
+			t.Errorf("frame %d is %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestClipRepeatedFrame(t *testing.T) {
+	c := NewClip(4, 1, 5)
+	defer c.Close()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for range 3 {
+		c.Add(img)
+	}
+	anim := <-c.GIF()
+	if len(anim.Image) != 1 || anim.Delay[0] != 15 {
+		t.Errorf("got %d frames with delays %v, want one frame of 15", len(anim.Image), anim.Delay)
+	}
+}
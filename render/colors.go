@@ -44,6 +44,18 @@ var (
 	ColorPlayerTread = glow.RGB(0, 100, 0)
 	ColorPlayerDark  = glow.RGB(0, 80, 0)
 
+	ColorPlayer2Body  = glow.RGB(70, 130, 230)
+	ColorPlayer2Tread = glow.RGB(40, 90, 180)
+	ColorPlayer2Dark  = glow.RGB(30, 65, 140)
+
+	ColorPlayer3Body  = glow.RGB(170, 90, 220)
+	ColorPlayer3Tread = glow.RGB(120, 60, 170)
+	ColorPlayer3Dark  = glow.RGB(90, 45, 130)
+
+	ColorPlayer4Body  = glow.RGB(0, 190, 190)
+	ColorPlayer4Tread = glow.RGB(0, 130, 130)
+	ColorPlayer4Dark  = glow.RGB(0, 95, 95)
+
 	// Enemy tanks
 	ColorEnemyBasicBody  = glow.RGB(190, 190, 190)
 	ColorEnemyBasicTread = glow.RGB(130, 130, 130)
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+)
+
+// DirectorStatus is what the director debug overlay shows.
+type DirectorStatus struct {
+	Enabled       bool
+	Intensity     float64 // -1 to 1
+	Target        float64
+	Deaths        float64
+	KillRate      float64 // kills per minute
+	Pace          float64
+	Walls         float64 // 0 to 1
+	SpawnInterval float64 // seconds
+	MaxActive     int
+	PowerUpEvery  int
+}
+
+// DrawDirectorOverlay draws the adaptive director's state in the top-left
+// corner of the play area, with a bar showing intensity and its target.
+func DrawDirectorOverlay(canvas *ScaledCanvas, d DirectorStatus) {
+	x, y := config.Padding+8, config.Padding+8
+	w, h := 232, 92
+	canvas.DrawRect(x, y, w, h, ColorBlack)
+	canvas.DrawRectOutline(x, y, w, h, ColorCyan)
+	x += 8
+	y += 8
+
+	if !d.Enabled {
+		DrawText(canvas, "DIRECTOR OFF", x, y, ColorGray, 1)
+		y += 12
+	} else {
+		DrawText(canvas, fmt.Sprintf("DIRECTOR %+.2f -> %+.2f", d.Intensity, d.Target), x, y, ColorCyan, 1)
+		y += 12
+
+		// Bar from -1 to 1 with the neutral point marked.
+		bw := w - 16
+		mid := x + bw/2
+		canvas.DrawRectOutline(x, y, bw, 10, ColorDarkGray)
+		canvas.DrawRect(mid, y, 1, 10, ColorGray)
+		fill := int(d.Intensity * float64(bw/2))
+		color := ColorYellow
+		if fill < 0 {
+			color = ColorCyan
+			canvas.DrawRect(mid+fill, y+2, -fill, 6, color)
+		} else {
+			canvas.DrawRect(mid, y+2, fill, 6, color)
+		}
+		canvas.DrawRect(mid+int(d.Target*float64(bw/2)), y, 2, 10, ColorWhite)
+		y += 16
+	}
+
+	DrawText(canvas, fmt.Sprintf("DEATHS %.1f  KILLS/MIN %.1f", d.Deaths, d.KillRate), x, y, ColorWhite, 1)
+	y += 12
+	DrawText(canvas, fmt.Sprintf("PACE %+.2f  WALLS %3.0f%%", d.Pace, d.Walls*100), x, y, ColorWhite, 1)
+	y += 12
+	DrawText(canvas, fmt.Sprintf("SPAWN %.1fS  MAX %d  DROP 1/%d", d.SpawnInterval, d.MaxActive, d.PowerUpEvery), x, y, ColorWhite, 1)
+}
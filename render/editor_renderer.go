@@ -0,0 +1,122 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/editor"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// editorHelp lists the construction-mode key bindings shown in the sidebar.
+var editorHelp = []string{
+	"ARROWS MOVE",
+	"SPACE  PAINT",
+	"TAB/1-6 TILE",
+	"B      BRUSH",
+	"E      EAGLE",
+	"P      PLAYER SPAWN",
+	"L      NEXT PLAYER",
+	"O      ENEMY SPAWN",
+	"Z/Y    UNDO/REDO",
+	"F2     SAVE",
+	"ENTER  TEST PLAY",
+	"ESC    QUIT",
+}
+
+// DrawEditor renders the construction screen: the level being edited with
+// its spawn markers and cursor, plus a sidebar with the tile palette.
+func DrawEditor(canvas *ScaledCanvas, r *Renderer, ed *editor.Editor, time float64) {
+	ox, oy := r.OffsetX, r.OffsetY
+	s := config.SubBlock
+
+	r.DrawPlayAreaBorder(canvas)
+	r.DrawGrid(canvas, ed.Grid)
+	r.DrawForest(canvas, ed.Grid)
+
+	// Spawn markers
+	for _, sp := range ed.Level.EnemySpawns {
+		canvas.DrawRectOutline(ox+sp[0]*s, oy+sp[1]*s, 2*s, 2*s, ColorRed)
+	}
+	for i, ps := range ed.Level.PlayerSpawns {
+		canvas.DrawRectOutline(ox+ps[0]*s, oy+ps[1]*s, 2*s, 2*s, ColorYellow)
+		DrawText(canvas, fmt.Sprintf("%dP", i+1), ox+ps[0]*s+8, oy+ps[1]*s+8, ColorYellow, 1)
+	}
+
+	// Cursor
+	n := ed.Brush.Size() * s
+	cx, cy := ox+ed.CursorX*s, oy+ed.CursorY*s
+	if int(time*4)%2 == 0 {
+		canvas.DrawRectOutline(cx, cy, n, n, ColorWhite)
+		canvas.DrawRectOutline(cx+1, cy+1, n-2, n-2, ColorWhite)
+	} else {
+		canvas.DrawRectOutline(cx, cy, n, n, ColorGray)
+	}
+
+	// Status line below the play area
+	DrawText(canvas, ed.Status, ox, oy+config.PlayAreaHeight+8, ColorHUDText, 1)
+
+	drawEditorSidebar(canvas, ed, r.Time)
+}
+
+func drawEditorSidebar(canvas *ScaledCanvas, ed *editor.Editor, time float64) {
+	hx := config.Padding + config.PlayAreaWidth + config.Padding
+	canvas.DrawRect(hx, 0, config.HUDWidth, config.WindowHeight, ColorHUDBG)
+
+	x := hx + 16
+	y := 24
+	DrawText(canvas, "CONSTRUCTION", x, y, ColorYellow, 1)
+	y += 14
+	name := strings.ToUpper(filepath.Base(ed.Path))
+	if ed.Dirty {
+		name += "*"
+	}
+	DrawText(canvas, name, x, y, ColorHUDText, 1)
+	y += 24
+
+	// Palette swatches
+	s := config.SubBlock
+	for i, t := range editor.Palette {
+		sx := x + (i%3)*(s*2+8)
+		sy := y + (i/3)*(s*2+8)
+		canvas.DrawRect(sx, sy, s*2, s*2, ColorBlack)
+		if t == world.TileForest {
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					drawForest(canvas, sx+dx*s, sy+dy*s, s)
+				}
+			}
+		} else if t != world.TileEmpty {
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					DrawTile(canvas, t, dx, dy, sx, sy, time)
+				}
+			}
+		}
+		DrawText(canvas, fmt.Sprint(i+1), sx+2, sy+2, ColorHUDText, 1)
+		if i == ed.Selected {
+			canvas.DrawRectOutline(sx-2, sy-2, s*2+4, s*2+4, ColorYellow)
+		}
+	}
+	y += ((len(editor.Palette)+2)/3)*(s*2+8) + 8
+
+	brush := "BLOCK"
+	if ed.Brush == editor.BrushQuarter {
+		brush = "QUARTER"
+	}
+	DrawText(canvas, "BRUSH "+brush, x, y, ColorHUDText, 1)
+	y += 14
+	DrawText(canvas, fmt.Sprintf("SPAWN %dP", ed.Spawn+1), x, y, ColorHUDText, 1)
+	y += 14
+	DrawText(canvas, fmt.Sprintf("X%02d Y%02d", ed.CursorX, ed.CursorY), x, y, ColorHUDText, 1)
+	y += 24
+
+	canvas.DrawRect(hx+8, y, config.HUDWidth-16, 2, ColorDarkGray)
+	y += 12
+	for _, line := range editorHelp {
+		DrawText(canvas, line, x, y, ColorHUDText, 1)
+		y += 12
+	}
+}
@@ -0,0 +1,241 @@
+package render
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata/golden from the current renderers")
+
+const (
+	// goldenTolerance is how far apart a channel of two pixels may be
+	// before they count as different.
+	goldenTolerance = 8
+	// goldenMaxDiff is the share of pixels that may differ before an image
+	// no longer matches its golden.
+	goldenMaxDiff = 0.002
+)
+
+// newTestCanvas returns a black target of the given size and an unscaled
+// canvas onto it whose logical origin is at (x, y).
+func newTestCanvas(x, y, width, height int) (*ImageTarget, *ScaledCanvas) {
+	t := NewImageTarget(width, height)
+	return t, NewScaledCanvas(t, config.Layout{Scale: 1, OffsetX: -x, OffsetY: -y})
+}
+
+// checkGolden compares img with testdata/golden/<name>.png, or rewrites
+// the golden with -update. On a mismatch the image drawn and a diff, with
+// differing pixels in red, are left in the temp directory.
+func checkGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".png")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := SavePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := loadPNG(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./render -update to create it)", err)
+	}
+	if want.Bounds() != img.Bounds() {
+		t.Fatalf("%s: drew %v but the golden is %v", name, img.Bounds().Size(), want.Bounds().Size())
+	}
+	diff, n := diffImages(want, img)
+	total := img.Bounds().Dx() * img.Bounds().Dy()
+	if float64(n) <= goldenMaxDiff*float64(total) {
+		return
+	}
+
+	dir := filepath.Join(os.TempDir(), "tankstrike-golden")
+	os.MkdirAll(dir, 0o755)
+	got := filepath.Join(dir, name+".png")
+	SavePNG(got, img)
+	SavePNG(filepath.Join(dir, name+".diff.png"), diff)
+	t.Errorf("%s: %d of %d pixels differ from %s; drew %s", name, n, total, path, got)
+}
+
+func loadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// diffImages returns an image of b with the pixels that differ from a in
+// red, and how many there are.
+func diffImages(a image.Image, b *image.RGBA) (*image.RGBA, int) {
+	r := b.Bounds()
+	diff := image.NewRGBA(r)
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			want := color.RGBAModel.Convert(a.At(x, y)).(color.RGBA)
+			got := b.RGBAAt(x, y)
+			if near(want.R, got.R) && near(want.G, got.G) && near(want.B, got.B) && near(want.A, got.A) {
+				// Dim what matches so the differences stand out.
+				diff.SetRGBA(x, y, color.RGBA{got.R / 4, got.G / 4, got.B / 4, 255})
+				continue
+			}
+			diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			n++
+		}
+	}
+	return diff, n
+}
+
+func near(a, b uint8) bool {
+	return abs(int(a)-int(b)) <= goldenTolerance
+}
+
+func TestDrawTank(t *testing.T) {
+	dirs := []struct {
+		name string
+		dir  entity.Direction
+	}{
+		{"up", entity.DirUp},
+		{"down", entity.DirDown},
+		{"left", entity.DirLeft},
+		{"right", entity.DirRight},
+	}
+	for _, d := range dirs {
+		for frame := 0; frame < 4; frame++ {
+			name := fmt.Sprintf("tank-%s-%d", d.name, frame)
+			t.Run(name, func(t *testing.T) {
+				tank := entity.NewTank(0, 0, 0, 1)
+				tank.Dir = d.dir
+				tank.TreadFrame = frame
+				img, canvas := newTestCanvas(-8, -8, config.TankSize+16, config.TankSize+16)
+				DrawTank(canvas, &tank, PlayerColors, 0, 0, 1)
+				checkGolden(t, name, img.Image())
+			})
+		}
+	}
+}
+
+func TestDrawTile(t *testing.T) {
+	tiles := []struct {
+		name string
+		tile world.TileType
+	}{
+		{"brick", world.TileBrick},
+		{"steel", world.TileSteel},
+		{"water", world.TileWater},
+		{"ice", world.TileIce},
+		{"forest", world.TileForest},
+		{"eagle", world.TileEagle},
+		{"eagle-dead", world.TileEagleDead},
+	}
+	for _, tc := range tiles {
+		name := "tile-" + tc.name
+		t.Run(name, func(t *testing.T) {
+			// A 2x2 block, as the tiles' patterns are laid out.
+			img, canvas := newTestCanvas(0, 0, config.SubBlock*2, config.SubBlock*2)
+			if tc.tile == world.TileForest {
+				g := world.NewGrid()
+				for y := 0; y < 2; y++ {
+					for x := 0; x < 2; x++ {
+						g.Set(x, y, world.TileForest)
+					}
+				}
+				DrawForestOverlay(canvas, g, 0, 0)
+			} else {
+				for y := 0; y < 2; y++ {
+					for x := 0; x < 2; x++ {
+						DrawTile(canvas, tc.tile, x, y, 0, 0, 0)
+					}
+				}
+			}
+			checkGolden(t, name, img.Image())
+		})
+	}
+}
+
+func TestDrawPowerUp(t *testing.T) {
+	types := []struct {
+		name string
+		typ  entity.PowerUpType
+	}{
+		{"star", entity.PowerUpStar},
+		{"tank", entity.PowerUpTank},
+		{"helmet", entity.PowerUpHelmet},
+		{"shovel", entity.PowerUpShovel},
+		{"bomb", entity.PowerUpBomb},
+		{"clock", entity.PowerUpClock},
+	}
+	for _, tc := range types {
+		name := "powerup-" + tc.name
+		t.Run(name, func(t *testing.T) {
+			img, canvas := newTestCanvas(-4, -4, 32, 32)
+			DrawPowerUp(canvas, &entity.PowerUp{Type: tc.typ, Active: true}, 0, 0)
+			checkGolden(t, name, img.Image())
+		})
+	}
+}
+
+func TestDrawHUD(t *testing.T) {
+	cases := []struct {
+		name     string
+		enemies  int
+		players  []PlayerStatus
+		level    int
+		timeLeft float64
+		muted    bool
+	}{
+		{"hud-1p", 20, []PlayerStatus{{Lives: 3}}, 0, -1, false},
+		{"hud-2p", 7, []PlayerStatus{{Lives: 2, Score: 12300, Stars: 2}, {Lives: 0, Score: 800, Stars: 3}}, 11, 65, false},
+		{"hud-timed-muted", 3, []PlayerStatus{{Lives: 1, Score: 4500, Stars: 1}}, 34, 8, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHUDRenderer()
+			img, canvas := newTestCanvas(h.X, 0, config.HUDWidth, config.WindowHeight)
+			h.DrawHUD(canvas, tc.enemies, tc.players, tc.level, tc.timeLeft, tc.muted)
+			checkGolden(t, tc.name, img.Image())
+		})
+	}
+}
+
+func TestMenuScreens(t *testing.T) {
+	options := []MenuOption{
+		{Label: "1 PLAYER"},
+		{Label: "2 PLAYERS"},
+		{Label: "CONTINUE", Disabled: true},
+		{Label: "DIFFICULTY: NORMAL"},
+	}
+	screens := []struct {
+		name string
+		draw func(*ScaledCanvas)
+	}{
+		{"menu-title", func(c *ScaledCanvas) { DrawTitleScreen(c, options, 1, 0) }},
+		{"menu-pause", func(c *ScaledCanvas) { DrawPauseScreen(c, 0) }},
+		{"menu-game-over", func(c *ScaledCanvas) { DrawGameOverScreen(c, 15400, false, 0) }},
+		{"menu-game-over-continue", func(c *ScaledCanvas) { DrawGameOverScreen(c, 15400, true, 0) }},
+		{"menu-level-intro", func(c *ScaledCanvas) { DrawLevelIntro(c, 2, "CROSSROADS") }},
+		{"menu-level-complete", func(c *ScaledCanvas) { DrawLevelComplete(c, 4, 23700, 9, 5, 3, 2, true, 0) }},
+	}
+	for _, tc := range screens {
+		t.Run(tc.name, func(t *testing.T) {
+			img, canvas := newTestCanvas(0, 0, config.WindowWidth, config.WindowHeight)
+			tc.draw(canvas)
+			checkGolden(t, tc.name, img.Image())
+		})
+	}
+}
@@ -18,8 +18,17 @@ func NewHUDRenderer() *HUDRenderer {
 	}
 }
 
-// DrawHUD draws the complete HUD sidebar.
-func (h *HUDRenderer) DrawHUD(canvas *ScaledCanvas, enemiesRemaining int, lives int, level int, score int, muted bool) {
+// PlayerStatus is the per-player information shown in the HUD.
+type PlayerStatus struct {
+	Lives int
+	Score int
+	Stars int
+}
+
+// DrawHUD draws the complete HUD sidebar with one panel per player.
+// timeLeft is the number of seconds left on a timed level, or negative
+// when the level has no time limit.
+func (h *HUDRenderer) DrawHUD(canvas *ScaledCanvas, enemiesRemaining int, players []PlayerStatus, level int, timeLeft float64, muted bool) {
 	// Background
 	canvas.DrawRect(h.X, 0, config.HUDWidth, config.WindowHeight, ColorHUDBG)
 
@@ -29,7 +38,7 @@ func (h *HUDRenderer) DrawHUD(canvas *ScaledCanvas, enemiesRemaining int, lives
 	// Enemy count icons (small red squares in a 2-column grid)
 	DrawText(canvas, "ENEMY", x, y, ColorHUDText, 1)
 	y += 16
-	for i := 0; i < enemiesRemaining; i++ {
+	for i := 0; i < enemiesRemaining && i < config.EnemiesPerLevel; i++ {
 		col := i % 2
 		row := i / 2
 		ix := x + col*20
@@ -44,28 +53,43 @@ func (h *HUDRenderer) DrawHUD(canvas *ScaledCanvas, enemiesRemaining int, lives
 	y += 12
 
 	// Player info
-	DrawText(canvas, "1P", x, y, ColorYellow, 1)
-	y += 14
-
-	// Lives
-	drawPlayerIcon(canvas, x, y)
-	DrawText(canvas, fmt.Sprintf("x%d", lives), x+20, y+2, ColorHUDText, 1)
-	y += 20
-
-	// Stars
-	DrawText(canvas, "SCORE", x, y, ColorHUDText, 1)
-	y += 12
-	DrawText(canvas, fmt.Sprintf("%06d", score), x, y, ColorYellow, 1)
-	y += 24
-
-	// Separator
-	canvas.DrawRect(h.X+8, y, config.HUDWidth-16, 2, ColorDarkGray)
-	y += 12
+	for i, p := range players {
+		DrawText(canvas, fmt.Sprintf("%dP", i+1), x, y, ColorYellow, 1)
+		for s := 0; s < p.Stars; s++ {
+			drawStarPip(canvas, x+28+s*12, y)
+		}
+		y += 14
+
+		// Lives
+		drawPlayerIcon(canvas, x, y, PlayerTankColors(i))
+		DrawText(canvas, fmt.Sprintf("x%d", p.Lives), x+20, y+2, ColorHUDText, 1)
+		y += 20
+
+		DrawText(canvas, "SCORE", x, y, ColorHUDText, 1)
+		y += 12
+		DrawText(canvas, fmt.Sprintf("%06d", p.Score), x, y, ColorYellow, 1)
+		y += 24
+
+		// Separator
+		canvas.DrawRect(h.X+8, y, config.HUDWidth-16, 2, ColorDarkGray)
+		y += 12
+	}
 
 	// Level
 	canvas.DrawRect(x, y, config.HUDWidth-40, 28, ColorHUDLevelBG)
 	DrawText(canvas, "STAGE", x+8, y+2, ColorHUDText, 1)
 	DrawText(canvas, fmt.Sprintf("  %2d", level+1), x+8, y+14, ColorYellow, 1)
+	y += 40
+
+	// Time limit
+	if timeLeft >= 0 {
+		color := ColorHUDText
+		if timeLeft < 10 {
+			color = ColorRed
+		}
+		DrawText(canvas, "TIME", x, y, ColorHUDText, 1)
+		DrawText(canvas, fmt.Sprintf("%d:%02d", int(timeLeft)/60, int(timeLeft)%60), x+48, y, color, 1)
+	}
 
 	// Mute indicator
 	if muted {
@@ -90,7 +114,13 @@ func drawEnemyIcon(canvas *ScaledCanvas, x, y int) {
 	canvas.DrawRect(x+4, y-2, 4, 3, ColorHUDEnemyIcon) // barrel
 }
 
-func drawPlayerIcon(canvas *ScaledCanvas, x, y int) {
-	canvas.DrawRect(x, y, 12, 14, ColorPlayerBody)
-	canvas.DrawRect(x+4, y-2, 4, 4, ColorPlayerTread) // barrel
+func drawPlayerIcon(canvas *ScaledCanvas, x, y int, colors TankColors) {
+	canvas.DrawRect(x, y, 12, 14, colors.Body)
+	canvas.DrawRect(x+4, y-2, 4, 4, colors.Tread) // barrel
+}
+
+func drawStarPip(canvas *ScaledCanvas, x, y int) {
+	canvas.DrawRect(x+3, y, 2, 8, ColorPowerUpStar)
+	canvas.DrawRect(x, y+3, 8, 2, ColorPowerUpStar)
+	canvas.DrawRect(x+2, y+2, 4, 4, ColorPowerUpStar)
 }
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/glow"
+)
+
+// DrawHostLobby renders the lobby of a LAN game this instance hosts: who
+// has joined, how many are watching and the netcode settings the match
+// will use.
+func DrawHostLobby(canvas *ScaledCanvas, port int, peers []string, watchers int, rollback bool, delay int, difficulty string, time float64) {
+	cx := config.WindowWidth / 2
+	canvas.Clear(glow.Black)
+	canvas.DrawRectOutline(20, 20, config.WindowWidth-40, config.WindowHeight-40, ColorDarkGray)
+
+	DrawTextCentered(canvas, "HOST LAN GAME", cx, 80, ColorYellow, 3)
+	DrawTextCentered(canvas, fmt.Sprintf("LISTENING ON UDP PORT %d", port), cx, 124, ColorGray, 1)
+	if watchers > 0 {
+		DrawTextCentered(canvas, fmt.Sprintf("%d WATCHING", watchers), cx, 140, ColorGray, 1)
+	}
+
+	y := 180
+	for i := 0; i < config.MaxPlayers; i++ {
+		name, color := "OPEN", ColorDarkGray
+		switch {
+		case i == 0:
+			name, color = "YOU", ColorWhite
+		case i <= len(peers):
+			name, color = peers[i-1], ColorWhite
+		}
+		canvas.DrawRect(cx-160, y+2, 12, 12, PlayerTankColors(i).Body)
+		DrawText(canvas, fmt.Sprintf("%dP", i+1), cx-140, y, ColorYellow, 2)
+		DrawText(canvas, name, cx-90, y+4, color, 1)
+		y += 32
+	}
+
+	y += 12
+	mode, hint := "LOCKSTEP", "RAISE DELAY IF THE GAME STUTTERS"
+	if rollback {
+		mode, hint = "ROLLBACK", "RAISE DELAY IF TANKS JUMP AROUND"
+	}
+	DrawTextCentered(canvas, "NETCODE  "+mode, cx, y, ColorWhite, 2)
+	y += 28
+	DrawTextCentered(canvas, fmt.Sprintf("INPUT DELAY  < %d TICKS >", delay), cx, y, ColorWhite, 2)
+	y += 28
+	DrawTextCentered(canvas, "DIFFICULTY  "+difficulty, cx, y, ColorWhite, 2)
+	y += 24
+	DrawTextCentered(canvas, "UP/DOWN NETCODE, LEFT/RIGHT DELAY", cx, y, ColorDarkGray, 1)
+	y += 14
+	DrawTextCentered(canvas, hint, cx, y, ColorDarkGray, 1)
+
+	if int(time*2)%2 == 0 {
+		prompt := "WAITING FOR PLAYERS TO JOIN"
+		if len(peers) > 0 {
+			prompt = "ENTER TO START, ESC TO CANCEL"
+		}
+		DrawTextCentered(canvas, prompt, cx, 520, ColorGray, 1)
+	}
+}
+
+// DrawJoinLobby renders the search for LAN games: the hosts found so far,
+// or, once one is joined, the wait for it to start the match.
+func DrawJoinLobby(canvas *ScaledCanvas, hosts []string, selected int, joined string, index, players int, rejected string, time float64) {
+	cx := config.WindowWidth / 2
+	canvas.Clear(glow.Black)
+	canvas.DrawRectOutline(20, 20, config.WindowWidth-40, config.WindowHeight-40, ColorDarkGray)
+
+	DrawTextCentered(canvas, "JOIN LAN GAME", cx, 80, ColorYellow, 3)
+
+	if joined != "" {
+		DrawTextCentered(canvas, joined, cx, 160, ColorWhite, 2)
+		if index == 0 {
+			DrawTextCentered(canvas, "CONNECTING...", cx, 200, ColorGray, 2)
+		} else {
+			DrawTextCentered(canvas, fmt.Sprintf("YOU ARE %dP OF %d", index+1, players), cx, 200, PlayerTankColors(index).Body, 2)
+		}
+		if int(time*2)%2 == 0 {
+			DrawTextCentered(canvas, "WAITING FOR THE HOST TO START", cx, 520, ColorGray, 1)
+		}
+		return
+	}
+
+	if rejected != "" {
+		DrawTextCentered(canvas, rejected, cx, 124, ColorRed, 1)
+	}
+	y := 180
+	if len(hosts) == 0 {
+		DrawTextCentered(canvas, "SEARCHING...", cx, y, ColorGray, 2)
+	}
+	for i, h := range hosts {
+		color := ColorGray
+		if i == selected {
+			color = ColorYellow
+			DrawText(canvas, ">", cx-160, y, ColorWhite, 2)
+		}
+		DrawTextCentered(canvas, h, cx, y, color, 2)
+		y += 30
+	}
+
+	if int(time*2)%2 == 0 {
+		DrawTextCentered(canvas, "ENTER TO JOIN, ESC TO CANCEL", cx, 520, ColorDarkGray, 1)
+	}
+}
+
+// DrawNetBanner shows the state of a networked match across the top of
+// the play area.
+func DrawNetBanner(canvas *ScaledCanvas, text string, color glow.Color) {
+	cx := config.Padding + config.PlayAreaWidth/2
+	w := TextWidth(text, 2) + 24
+	canvas.DrawRect(cx-w/2, config.Padding+8, w, 28, ColorBlack)
+	canvas.DrawRectOutline(cx-w/2, config.Padding+8, w, 28, color)
+	DrawTextCentered(canvas, text, cx, config.Padding+15, color, 2)
+}
@@ -2,6 +2,7 @@ package render
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/AchrafSoltani/TankStrike/config"
 	"github.com/AchrafSoltani/glow"
@@ -32,7 +33,7 @@ func DrawTitleScreen(canvas *ScaledCanvas, options []MenuOption, selected int, t
 	drawMenuTankArt(canvas, cx-80, 240)
 
 	// Menu options
-	optY := 380
+	optY := 360
 	for i, opt := range options {
 		color := ColorGray
 		if opt.Disabled {
@@ -43,12 +44,12 @@ func DrawTitleScreen(canvas *ScaledCanvas, options []MenuOption, selected int, t
 			DrawText(canvas, ">", cx-120, optY, ColorWhite, 2)
 		}
 		DrawTextCentered(canvas, opt.Label, cx, optY, color, 2)
-		optY += 30
+		optY += 26
 	}
 
 	// Flashing prompt
 	if int(time*2)%2 == 0 {
-		DrawTextCentered(canvas, "UP/DOWN TO SELECT, ENTER TO CONFIRM", cx, 520, ColorDarkGray, 1)
+		DrawTextCentered(canvas, "UP/DOWN TO SELECT, LEFT/RIGHT TO CHANGE, ENTER TO CONFIRM", cx, 548, ColorDarkGray, 1)
 	}
 
 	// Credits
@@ -121,7 +122,7 @@ func DrawGameOverScreen(canvas *ScaledCanvas, score int, canContinue bool, time
 }
 
 // DrawLevelIntro renders the level introduction screen.
-func DrawLevelIntro(canvas *ScaledCanvas, level int) {
+func DrawLevelIntro(canvas *ScaledCanvas, level int, name string) {
 	canvas.Clear(glow.RGB(40, 40, 40))
 
 	cx := config.WindowWidth / 2
@@ -133,6 +134,10 @@ func DrawLevelIntro(canvas *ScaledCanvas, level int) {
 	// Decorative lines
 	lineW := TextWidth(text, 4)
 	canvas.DrawRect(cx-lineW/2, cy+20, lineW, 2, ColorYellow)
+
+	if name != "" {
+		DrawTextCentered(canvas, strings.ToUpper(name), cx, cy+36, ColorGray, 2)
+	}
 }
 
 // DrawLevelComplete renders the level complete tally.
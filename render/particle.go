@@ -2,7 +2,7 @@ package render
 
 import (
 	"math"
-	"math/rand"
+	"math/rand/v2"
 
 	"github.com/AchrafSoltani/TankStrike/config"
 	"github.com/AchrafSoltani/glow"
@@ -23,11 +23,19 @@ type Particle struct {
 // ParticlePool manages a fixed-size pool of particles.
 type ParticlePool struct {
 	Particles [config.MaxParticles]Particle
+	rng       *rand.Rand // cosmetic stream, never shared with gameplay
 }
 
-// NewParticlePool creates a new particle pool.
-func NewParticlePool() *ParticlePool {
-	return &ParticlePool{}
+// NewParticlePool creates a new particle pool drawing from rng.
+func NewParticlePool(rng *rand.Rand) *ParticlePool {
+	return &ParticlePool{rng: rng}
+}
+
+// Reset deactivates every particle.
+func (pp *ParticlePool) Reset() {
+	for i := range pp.Particles {
+		pp.Particles[i].Active = false
+	}
 }
 
 // Emit activates a particle with the given properties.
@@ -49,14 +57,14 @@ func (pp *ParticlePool) Emit(x, y, vx, vy, life, size float64, color glow.Color,
 func (pp *ParticlePool) SpawnExplosion(x, y float64, count int) {
 	colors := []glow.Color{ColorExplosion1, ColorExplosion2, ColorExplosion3, ColorExplosion4}
 	for i := 0; i < count; i++ {
-		angle := rand.Float64() * math.Pi * 2
-		speed := 40 + rand.Float64()*120
+		angle := pp.rng.Float64() * math.Pi * 2
+		speed := 40 + pp.rng.Float64()*120
 		vx := math.Cos(angle) * speed
 		vy := math.Sin(angle) * speed
-		life := 0.3 + rand.Float64()*0.5
-		size := 2 + rand.Float64()*4
-		color := colors[rand.Intn(len(colors))]
-		isCircle := rand.Float64() > 0.5
+		life := 0.3 + pp.rng.Float64()*0.5
+		size := 2 + pp.rng.Float64()*4
+		color := colors[pp.rng.IntN(len(colors))]
+		isCircle := pp.rng.Float64() > 0.5
 		pp.Emit(x, y, vx, vy, life, size, color, isCircle)
 	}
 }
@@ -64,11 +72,11 @@ func (pp *ParticlePool) SpawnExplosion(x, y float64, count int) {
 // SpawnSpark creates small sparks (for bullet hitting steel).
 func (pp *ParticlePool) SpawnSpark(x, y float64) {
 	for i := 0; i < 8; i++ {
-		angle := rand.Float64() * math.Pi * 2
-		speed := 30 + rand.Float64()*80
+		angle := pp.rng.Float64() * math.Pi * 2
+		speed := 30 + pp.rng.Float64()*80
 		vx := math.Cos(angle) * speed
 		vy := math.Sin(angle) * speed
-		life := 0.1 + rand.Float64()*0.2
+		life := 0.1 + pp.rng.Float64()*0.2
 		pp.Emit(x, y, vx, vy, life, 2, ColorSpark, false)
 	}
 }
@@ -77,13 +85,13 @@ func (pp *ParticlePool) SpawnSpark(x, y float64) {
 func (pp *ParticlePool) SpawnDebris(x, y float64) {
 	colors := []glow.Color{ColorDebris1, ColorDebris2, ColorBrick}
 	for i := 0; i < 12; i++ {
-		angle := rand.Float64() * math.Pi * 2
-		speed := 20 + rand.Float64()*60
+		angle := pp.rng.Float64() * math.Pi * 2
+		speed := 20 + pp.rng.Float64()*60
 		vx := math.Cos(angle) * speed
 		vy := math.Sin(angle)*speed - 20
-		life := 0.3 + rand.Float64()*0.4
-		size := 2 + rand.Float64()*3
-		color := colors[rand.Intn(len(colors))]
+		life := 0.3 + pp.rng.Float64()*0.4
+		size := 2 + pp.rng.Float64()*3
+		color := colors[pp.rng.IntN(len(colors))]
 		pp.Emit(x, y, vx, vy, life, size, color, false)
 	}
 }
@@ -5,16 +5,16 @@ import (
 	"github.com/AchrafSoltani/glow"
 )
 
-// ScaledCanvas wraps a glow.Canvas and transparently scales all draw
+// ScaledCanvas wraps a Target and transparently scales all draw
 // operations according to a config.Layout. Game code continues to use
 // the original 864x672 coordinate system.
 type ScaledCanvas struct {
-	canvas *glow.Canvas
+	canvas Target
 	layout config.Layout
 }
 
-// NewScaledCanvas creates a ScaledCanvas from a raw canvas and layout.
-func NewScaledCanvas(canvas *glow.Canvas, layout config.Layout) *ScaledCanvas {
+// NewScaledCanvas creates a ScaledCanvas from a raw target and layout.
+func NewScaledCanvas(canvas Target, layout config.Layout) *ScaledCanvas {
 	return &ScaledCanvas{canvas: canvas, layout: layout}
 }
 
@@ -30,7 +30,7 @@ func (sc *ScaledCanvas) ts(v int) int {
 	return int(float64(v) * sc.layout.Scale)
 }
 
-// Clear fills the entire underlying canvas.
+// Clear fills the entire underlying target.
 func (sc *ScaledCanvas) Clear(color glow.Color) {
 	sc.canvas.Clear(color)
 }
@@ -14,7 +14,10 @@ type TankColors struct {
 }
 
 var (
-	PlayerColors = TankColors{ColorPlayerBody, ColorPlayerTread, ColorPlayerDark}
+	PlayerColors  = TankColors{ColorPlayerBody, ColorPlayerTread, ColorPlayerDark}
+	Player2Colors = TankColors{ColorPlayer2Body, ColorPlayer2Tread, ColorPlayer2Dark}
+	Player3Colors = TankColors{ColorPlayer3Body, ColorPlayer3Tread, ColorPlayer3Dark}
+	Player4Colors = TankColors{ColorPlayer4Body, ColorPlayer4Tread, ColorPlayer4Dark}
 
 	EnemyBasicColors  = TankColors{ColorEnemyBasicBody, ColorEnemyBasicTread, glow.RGB(120, 120, 120)}
 	EnemyFastColors   = TankColors{ColorEnemyFastBody, ColorEnemyFastTread, glow.RGB(180, 150, 0)}
@@ -22,14 +25,30 @@ var (
 	EnemyArmourColors = TankColors{ColorEnemyArmourBody, ColorEnemyArmourTread, glow.RGB(0, 120, 60)}
 )
 
-// DrawTank draws a tank at its position with the given colour scheme.
-func DrawTank(canvas *ScaledCanvas, t *entity.Tank, colors TankColors, offsetX, offsetY int) {
+// PlayerTankColors returns the colour scheme for player index.
+func PlayerTankColors(index int) TankColors {
+	switch index {
+	case 1:
+		return Player2Colors
+	case 2:
+		return Player3Colors
+	case 3:
+		return Player4Colors
+	default:
+		return PlayerColors
+	}
+}
+
+// DrawTank draws a tank with the given colour scheme, interpolated alpha
+// (0-1) of the way from its previous tick position to its current one.
+func DrawTank(canvas *ScaledCanvas, t *entity.Tank, colors TankColors, offsetX, offsetY int, alpha float64) {
 	if !t.Alive {
 		return
 	}
 
-	px := int(t.X) + offsetX
-	py := int(t.Y) + offsetY
+	x, y := t.Interpolate(alpha)
+	px := int(x) + offsetX
+	py := int(y) + offsetY
 	size := config.TankSize
 
 	// Centre of tank
@@ -135,13 +154,14 @@ func drawTread(canvas *ScaledCanvas, x, y, w, h int, vertical bool, frame int, c
 }
 
 // DrawShield draws the invulnerability shield around a tank.
-func DrawShield(canvas *ScaledCanvas, t *entity.Tank, offsetX, offsetY int, time float64) {
-	cx := int(t.X) + offsetX + config.TankSize/2
-	cy := int(t.Y) + offsetY + config.TankSize/2
+func DrawShield(canvas *ScaledCanvas, t *entity.Tank, offsetX, offsetY int, alpha, time float64) {
+	x, y := t.Interpolate(alpha)
+	cx := int(x) + offsetX + config.TankSize/2
+	cy := int(y) + offsetY + config.TankSize/2
 
 	// Pulsing shield
-	alpha := int(time*10) % 2
-	if alpha == 0 {
+	pulse := int(time*10) % 2
+	if pulse == 0 {
 		canvas.DrawCircle(cx, cy, config.TankSize/2+2, ColorWhite)
 		canvas.DrawCircle(cx, cy, config.TankSize/2+3, ColorCyan)
 	}
@@ -0,0 +1,170 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/AchrafSoltani/glow"
+)
+
+// Target is a surface the renderers draw on, in its own pixels. A live
+// *glow.Canvas is one; an ImageTarget draws offscreen.
+type Target interface {
+	Clear(color glow.Color)
+	SetPixel(x, y int, color glow.Color)
+	DrawRect(x, y, width, height int, color glow.Color)
+	DrawRectOutline(x, y, width, height int, color glow.Color)
+	FillCircle(x, y, radius int, color glow.Color)
+	DrawCircle(x, y, radius int, color glow.Color)
+	DrawLine(x0, y0, x1, y1 int, color glow.Color)
+}
+
+var _ Target = (*glow.Canvas)(nil)
+
+// ImageTarget draws into an in-memory RGBA image, for rendering without a
+// window.
+type ImageTarget struct {
+	img *image.RGBA
+}
+
+// NewImageTarget creates a black image target of the given size.
+func NewImageTarget(width, height int) *ImageTarget {
+	t := &ImageTarget{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	t.Clear(glow.Black)
+	return t
+}
+
+// Image returns the image drawn so far.
+func (t *ImageTarget) Image() *image.RGBA {
+	return t.img
+}
+
+// SavePNG writes the image to path as a PNG.
+func (t *ImageTarget) SavePNG(path string) error {
+	return SavePNG(path, t.img)
+}
+
+// SavePNG writes img to path as a PNG.
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func rgba(c glow.Color) color.RGBA {
+	return color.RGBA{c.R, c.G, c.B, c.A}
+}
+
+// Clear fills the whole image.
+func (t *ImageTarget) Clear(c glow.Color) {
+	t.DrawRect(0, 0, t.img.Rect.Dx(), t.img.Rect.Dy(), c)
+}
+
+// SetPixel sets one pixel, if it is inside the image.
+func (t *ImageTarget) SetPixel(x, y int, c glow.Color) {
+	if image.Pt(x, y).In(t.img.Rect) {
+		t.img.SetRGBA(x, y, rgba(c))
+	}
+}
+
+// DrawRect fills a rectangle, clipped to the image.
+func (t *ImageTarget) DrawRect(x, y, width, height int, c glow.Color) {
+	r := image.Rect(x, y, x+width, y+height).Intersect(t.img.Rect)
+	if r.Empty() {
+		return
+	}
+	px := rgba(c)
+	for yy := r.Min.Y; yy < r.Max.Y; yy++ {
+		row := t.img.Pix[t.img.PixOffset(r.Min.X, yy):t.img.PixOffset(r.Max.X, yy)]
+		for i := 0; i < len(row); i += 4 {
+			row[i], row[i+1], row[i+2], row[i+3] = px.R, px.G, px.B, px.A
+		}
+	}
+}
+
+// DrawRectOutline draws the one-pixel border of a rectangle.
+func (t *ImageTarget) DrawRectOutline(x, y, width, height int, c glow.Color) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	t.DrawRect(x, y, width, 1, c)
+	t.DrawRect(x, y+height-1, width, 1, c)
+	t.DrawRect(x, y, 1, height, c)
+	t.DrawRect(x+width-1, y, 1, height, c)
+}
+
+// FillCircle fills every pixel within radius of the centre.
+func (t *ImageTarget) FillCircle(cx, cy, radius int, c glow.Color) {
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				t.SetPixel(cx+dx, cy+dy, c)
+			}
+		}
+	}
+}
+
+// DrawCircle draws a circle outline with the midpoint algorithm.
+func (t *ImageTarget) DrawCircle(cx, cy, radius int, c glow.Color) {
+	x, y := radius, 0
+	err := 1 - radius
+	for x >= y {
+		for _, p := range [8][2]int{
+			{x, y}, {y, x}, {-y, x}, {-x, y},
+			{-x, -y}, {-y, -x}, {y, -x}, {x, -y},
+		} {
+			t.SetPixel(cx+p[0], cy+p[1], c)
+		}
+		y++
+		if err < 0 {
+			err += 2*y + 1
+		} else {
+			x--
+			err += 2*(y-x) + 1
+		}
+	}
+}
+
+// DrawLine draws a line between two points, both included, with
+// Bresenham's algorithm.
+func (t *ImageTarget) DrawLine(x0, y0, x1, y1 int, c glow.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		t.SetPixel(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+package replay
+
+import (
+	"github.com/AchrafSoltani/TankStrike/sim"
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+// Playback feeds a recorded match back into a simulation.
+type Playback struct {
+	Replay     *Replay
+	Tick       int // ticks played so far
+	DesyncTick int // first tick whose checksum did not match, or 0
+}
+
+// NewPlayback prepares r for playback.
+func NewPlayback(r *Replay) *Playback {
+	return &Playback{Replay: r}
+}
+
+// Start seeds s from the replay, starts the match and primes each player's
+// input with the buttons that were held when recording began.
+func (p *Playback) Start(s *sim.Sim, inputs []*system.Input) {
+	s.Seed = p.Replay.Seed
+	s.Difficulty = p.Replay.Difficulty
+	s.Adaptive = p.Replay.Adaptive
+	s.Start(p.Replay.Level, p.Replay.Players)
+	for i, in := range inputs {
+		if i < len(p.Replay.Initial) {
+			in.Update(p.Replay.Initial[i])
+		}
+	}
+	p.Tick = 0
+	p.DesyncTick = 0
+}
+
+// Next returns each player's buttons for the next tick, or false once the
+// recording is exhausted.
+func (p *Playback) Next() ([]system.Button, bool) {
+	if p.Done() {
+		return nil, false
+	}
+	b := p.Replay.Inputs[p.Tick]
+	p.Tick++
+	return b, true
+}
+
+// Done returns true when every recorded tick has been played.
+func (p *Playback) Done() bool {
+	return p.Tick >= len(p.Replay.Inputs)
+}
+
+// Verify compares the state of s against the recorded checksum for the
+// tick just played, if one is due. It returns false on the first mismatch.
+func (p *Playback) Verify(s *sim.Sim) bool {
+	if p.DesyncTick != 0 || p.Replay.Interval <= 0 || p.Tick%p.Replay.Interval != 0 {
+		return true
+	}
+	i := p.Tick/p.Replay.Interval - 1
+	if i >= len(p.Replay.Checksums) || p.Replay.Checksums[i] == s.Checksum() {
+		return true
+	}
+	p.DesyncTick = p.Tick
+	return false
+}
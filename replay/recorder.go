@@ -0,0 +1,46 @@
+package replay
+
+import (
+	"slices"
+
+	"github.com/AchrafSoltani/TankStrike/sim"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// Recorder captures the input of a single match.
+type Recorder struct {
+	Replay Replay
+}
+
+// NewRecorder starts a recording for a match that has just been started
+// on s, with initial[p] the buttons player p already held.
+func NewRecorder(version string, s *sim.Sim, initial []system.Button) *Recorder {
+	return &Recorder{
+		Replay: Replay{
+			Version:    version,
+			Seed:       s.Seed,
+			Level:      s.Level,
+			Players:    len(s.Players),
+			Difficulty: s.Difficulty,
+			Adaptive:   s.Adaptive,
+			LevelSet:   world.HashLevels(s.Levels),
+			Initial:    slices.Clone(initial),
+			Interval:   CheckInterval,
+		},
+	}
+}
+
+// Record appends the buttons each player used for the tick just simulated
+// on s and, every Interval ticks, the resulting state checksum.
+func (r *Recorder) Record(held []system.Button, s *sim.Sim) {
+	r.Replay.Inputs = append(r.Replay.Inputs, slices.Clone(held))
+	if len(r.Replay.Inputs)%r.Replay.Interval == 0 {
+		r.Replay.Checksums = append(r.Replay.Checksums, s.Checksum())
+	}
+}
+
+// Save writes the recording to path.
+func (r *Recorder) Save(path string) error {
+	return r.Replay.Save(path)
+}
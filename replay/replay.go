@@ -0,0 +1,283 @@
+// Package replay records the per-tick input of a match to a compact .tsr
+// file and plays it back, checking periodic state checksums for desync.
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// File layout (all integers are unsigned varints unless noted):
+//
+//	magic      "TSR" + format byte
+//	version    length-prefixed game version string
+//	seed       uint64, little endian
+//	level      starting level index
+//	players    number of players (format 2 and later)
+//	difficulty difficulty preset (format 3 and later)
+//	adaptive   1 if the director was enabled, else 0 (format 4 and later)
+//	levels     uint64 hash of the level set, little endian (format 5 and later)
+//	per player:
+//	  initial  buttons held before the first tick
+//	  runs     number of input runs, then (length, buttons) per run
+//	interval   ticks between checksums
+//	checksums  count, then one uint64 (little endian) per checksum
+//
+// Format 1 files have no player count and a single player's input.
+// Files before format 3 were all played on Normal, and files before
+// format 4 without the director. Files before format 5 do not record
+// their level set, so it cannot be checked.
+const (
+	magic         = "TSR"
+	formatVersion = 5
+)
+
+// CheckInterval is the number of ticks between recorded state checksums.
+const CheckInterval = 60
+
+// Bounds on the sizes accepted from a file, so a corrupt one cannot
+// exhaust memory.
+const (
+	maxVersionLen = 64
+	maxTicks      = 2 * 60 * 60 * config.TickRate // two hours of play
+)
+
+// ErrBadFormat is returned when a file is not a replay this build can read.
+var ErrBadFormat = errors.New("replay: not a TankStrike replay file")
+
+// ErrLevelMismatch is returned when a replay was recorded on a different
+// level set from the one it is about to be played on.
+var ErrLevelMismatch = errors.New("replay: recorded on a different level set")
+
+// Replay is the full content of a replay file.
+type Replay struct {
+	Version    string            // game version that recorded the replay
+	Seed       uint64            // simulation seed
+	Level      int               // level index the match started on
+	Players    int               // number of players in the match
+	Difficulty config.Difficulty // balance preset of the match
+	Adaptive   bool              // whether the match ran the director
+	LevelSet   uint64            // world.HashLevels of the campaign, or 0 if not recorded
+	Initial    []system.Button   // buttons each player held before the first tick
+	Inputs     [][]system.Button // Inputs[t][p] is the buttons player p held on tick t
+	Interval   int               // ticks between checksums
+	Checksums  []uint64          // Checksums[i] is the state after tick (i+1)*Interval
+}
+
+// Save writes the replay to path.
+func (r *Replay) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a replay from path.
+func Load(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// CheckLevels returns ErrLevelMismatch if the replay records a level set
+// other than levels.
+func (r *Replay) CheckLevels(levels []*world.Level) error {
+	if r.LevelSet != 0 && r.LevelSet != world.HashLevels(levels) {
+		return ErrLevelMismatch
+	}
+	return nil
+}
+
+// Encode writes the replay in .tsr format.
+func (r *Replay) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(buf[:], v)
+		bw.Write(buf[:n])
+	}
+	putUint64 := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf[:8], v)
+		bw.Write(buf[:8])
+	}
+
+	bw.WriteString(magic)
+	bw.WriteByte(formatVersion)
+	putUvarint(uint64(len(r.Version)))
+	bw.WriteString(r.Version)
+	putUint64(r.Seed)
+	putUvarint(uint64(r.Level))
+	putUvarint(uint64(r.Players))
+	putUvarint(uint64(r.Difficulty))
+	if r.Adaptive {
+		putUvarint(1)
+	} else {
+		putUvarint(0)
+	}
+	putUint64(r.LevelSet)
+	for p := 0; p < r.Players; p++ {
+		putUvarint(uint64(r.Initial[p]))
+		runs := encodeRuns(r.Inputs, p)
+		putUvarint(uint64(len(runs)))
+		for _, run := range runs {
+			putUvarint(uint64(run.length))
+			putUvarint(uint64(run.buttons))
+		}
+	}
+
+	putUvarint(uint64(r.Interval))
+	putUvarint(uint64(len(r.Checksums)))
+	for _, c := range r.Checksums {
+		putUint64(c)
+	}
+	return bw.Flush()
+}
+
+// Decode reads a replay in .tsr format.
+func Decode(rd io.Reader) (*Replay, error) {
+	br := bufio.NewReader(rd)
+	var err error
+	getUvarint := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		v, rerr := binary.ReadUvarint(br)
+		if rerr != nil {
+			err = rerr
+			return 0
+		}
+		return v
+	}
+	getUint64 := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var b [8]byte
+		_, err = io.ReadFull(br, b[:])
+		return binary.LittleEndian.Uint64(b[:])
+	}
+
+	head := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, head); err != nil || string(head[:len(magic)]) != magic {
+		return nil, ErrBadFormat
+	}
+	format := head[len(magic)]
+	if format < 1 || format > formatVersion {
+		return nil, fmt.Errorf("replay: unsupported format version %d", format)
+	}
+
+	r := &Replay{}
+	n := getUvarint()
+	if err == nil && n > maxVersionLen {
+		return nil, ErrBadFormat
+	}
+	version := make([]byte, n)
+	if err == nil {
+		_, err = io.ReadFull(br, version)
+	}
+	r.Version = string(version)
+	r.Seed = getUint64()
+	r.Level = int(getUvarint())
+	if err == nil && r.Level < 0 {
+		return nil, ErrBadFormat
+	}
+	r.Players = 1
+	if format >= 2 {
+		r.Players = int(getUvarint())
+	}
+	if err == nil && (r.Players < 1 || r.Players > config.MaxPlayers) {
+		return nil, ErrBadFormat
+	}
+	r.Difficulty = config.DifficultyNormal
+	if format >= 3 {
+		r.Difficulty = config.Difficulty(getUvarint())
+	}
+	if err == nil && (r.Difficulty < 0 || r.Difficulty >= config.DifficultyCount) {
+		return nil, ErrBadFormat
+	}
+	if format >= 4 {
+		r.Adaptive = getUvarint() != 0
+	}
+	if format >= 5 {
+		r.LevelSet = getUint64()
+	}
+	r.Initial = make([]system.Button, r.Players)
+	for p := 0; p < r.Players && err == nil; p++ {
+		r.Initial[p] = system.Button(getUvarint())
+		t := 0
+		nRuns := getUvarint()
+		if err == nil && nRuns > maxTicks {
+			return nil, ErrBadFormat
+		}
+		for i := uint64(0); i < nRuns && err == nil; i++ {
+			length := getUvarint()
+			buttons := system.Button(getUvarint())
+			if err == nil && length > maxTicks-uint64(t) {
+				return nil, ErrBadFormat
+			}
+			for j := uint64(0); j < length && err == nil; j++ {
+				if p == 0 {
+					r.Inputs = append(r.Inputs, make([]system.Button, r.Players))
+				} else if t >= len(r.Inputs) {
+					return nil, ErrBadFormat
+				}
+				r.Inputs[t][p] = buttons
+				t++
+			}
+		}
+		if err == nil && t != len(r.Inputs) {
+			return nil, ErrBadFormat
+		}
+	}
+
+	r.Interval = int(getUvarint())
+	nSums := getUvarint()
+	if err == nil && nSums > maxTicks {
+		return nil, ErrBadFormat
+	}
+	for i := uint64(0); i < nSums && err == nil; i++ {
+		r.Checksums = append(r.Checksums, getUint64())
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("replay: truncated file: %w", err)
+	}
+	return r, nil
+}
+
+type run struct {
+	length  int
+	buttons system.Button
+}
+
+// encodeRuns run-length encodes player p's per-tick input; held buttons
+// rarely change between 120 Hz ticks so this shrinks files by two orders
+// of magnitude.
+func encodeRuns(inputs [][]system.Button, p int) []run {
+	var runs []run
+	for _, tick := range inputs {
+		b := tick[p]
+		if n := len(runs); n > 0 && runs[n-1].buttons == b {
+			runs[n-1].length++
+			continue
+		}
+		runs = append(runs, run{length: 1, buttons: b})
+	}
+	return runs
+}
@@ -0,0 +1,176 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// testReplay returns a short two-player recording on the built-in
+// campaign.
+func testReplay() *Replay {
+	r := &Replay{
+		Version:    "1.0",
+		Seed:       42,
+		Level:      3,
+		Players:    2,
+		Difficulty: config.DifficultyHard,
+		Adaptive:   true,
+		LevelSet:   world.HashLevels(world.Levels),
+		Initial:    []system.Button{system.ButtonUp, 0},
+		Interval:   4,
+	}
+	for t := 0; t < 10; t++ {
+		b := system.ButtonFire
+		if t > 5 {
+			b = system.ButtonLeft
+		}
+		r.Inputs = append(r.Inputs, []system.Button{b, system.Button(t % 2)})
+	}
+	r.Checksums = []uint64{1, 2}
+	return r
+}
+
+func encode(t testing.TB, r *Replay) []byte {
+	var b bytes.Buffer
+	if err := r.Encode(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// builder writes raw replay fields, for files Encode would never make.
+type builder struct {
+	bytes.Buffer
+}
+
+func (b *builder) uvarint(v uint64) *builder {
+	b.Write(binary.AppendUvarint(nil, v))
+	return b
+}
+
+func (b *builder) uint64(v uint64) *builder {
+	b.Write(binary.LittleEndian.AppendUint64(nil, v))
+	return b
+}
+
+// newBuilder starts a current-format file.
+func newBuilder() *builder {
+	b := &builder{}
+	b.WriteString(magic)
+	b.WriteByte(formatVersion)
+	return b
+}
+
+// header writes a current-format file up to the first player's input.
+func header(players, difficulty uint64) *builder {
+	b := newBuilder().uvarint(1)
+	b.WriteString("v")
+	b.uint64(1)  // seed
+	b.uvarint(0) // level
+	b.uvarint(players)
+	b.uvarint(difficulty)
+	b.uvarint(0) // adaptive
+	b.uint64(0)  // level set
+	return b
+}
+
+func TestRoundTrip(t *testing.T) {
+	want := testReplay()
+	got, err := Decode(bytes.NewReader(encode(t, want)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	data := encode(t, testReplay())
+	for n := 0; n < len(data); n++ {
+		if _, err := Decode(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("decoded the first %d of %d bytes", n, len(data))
+		}
+	}
+}
+
+func TestDecodeBad(t *testing.T) {
+	huge := uint64(math.MaxInt64) + 2 // negative as an int
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bad magic", []byte("TSX\x05")},
+		{"oversized version", newBuilder().uvarint(maxVersionLen + 1).Bytes()},
+		{"no players", header(0, 0).Bytes()},
+		{"too many players", header(config.MaxPlayers+1, 0).Bytes()},
+		{"negative players", header(huge, 0).Bytes()},
+		{"negative level", newBuilder().uvarint(0).uint64(1).uvarint(huge).Bytes()},
+		{"unknown difficulty", header(1, uint64(config.DifficultyCount)).Bytes()},
+		{"negative difficulty", header(1, huge).Bytes()},
+		{"oversized run count", header(1, 0).uvarint(0).uvarint(maxTicks + 1).Bytes()},
+		{"oversized run", header(1, 0).uvarint(0).uvarint(1).uvarint(maxTicks + 1).uvarint(0).Bytes()},
+		{"second player runs longer", header(2, 0).
+			uvarint(0).uvarint(1).uvarint(1).uvarint(0).
+			uvarint(0).uvarint(1).uvarint(2).uvarint(0).Bytes()},
+		{"second player runs shorter", header(2, 0).
+			uvarint(0).uvarint(1).uvarint(2).uvarint(0).
+			uvarint(0).uvarint(1).uvarint(1).uvarint(0).Bytes()},
+		{"oversized checksum count", header(1, 0).uvarint(0).uvarint(0).uvarint(60).uvarint(maxTicks + 1).Bytes()},
+	}
+	for _, tc := range tests {
+		if _, err := Decode(bytes.NewReader(tc.data)); !errors.Is(err, ErrBadFormat) {
+			t.Errorf("%s: got %v, want ErrBadFormat", tc.name, err)
+		}
+	}
+}
+
+func TestDecodeUnsupportedFormat(t *testing.T) {
+	data := encode(t, testReplay())
+	data[len(magic)] = formatVersion + 1
+	if _, err := Decode(bytes.NewReader(data)); err == nil {
+		t.Error("decoded a file from a newer format")
+	}
+}
+
+func TestCheckLevels(t *testing.T) {
+	r := testReplay()
+	if err := r.CheckLevels(world.Levels); err != nil {
+		t.Errorf("built-in campaign: %v", err)
+	}
+	if err := r.CheckLevels(world.Levels[1:]); !errors.Is(err, ErrLevelMismatch) {
+		t.Errorf("other campaign: got %v, want ErrLevelMismatch", err)
+	}
+	r.LevelSet = 0
+	if err := r.CheckLevels(world.Levels[1:]); err != nil {
+		t.Errorf("unrecorded level set: %v", err)
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	data := encode(f, testReplay())
+	f.Add(data)
+	f.Add(data[:len(data)/2])
+	f.Add(header(1, 0).uvarint(0).uvarint(1).uvarint(3).uvarint(0).uvarint(1).uvarint(0).Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		again, err := Decode(bytes.NewReader(encode(t, r)))
+		if err != nil {
+			t.Fatalf("re-encoded replay does not decode: %v", err)
+		}
+		if !reflect.DeepEqual(again, r) {
+			t.Fatalf("re-encoded replay decodes differently\n%+v\n%+v", again, r)
+		}
+	})
+}
@@ -4,15 +4,46 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/AchrafSoltani/TankStrike/config"
 )
 
-// SaveData holds persistent game state.
-type SaveData struct {
+// Progress is how far the player has got on one difficulty.
+type Progress struct {
 	HighScore int `json:"high_score"`
 	MaxLevel  int `json:"max_level"`
 }
 
-func configDir() string {
+// SaveData holds persistent game state. Progress is kept per difficulty,
+// so scores are only ever compared between matches played with the same
+// balance.
+type SaveData struct {
+	Difficulty string               `json:"difficulty"` // preset last chosen
+	Progress   map[string]*Progress `json:"progress"`   // keyed by preset name
+
+	// Progress saved before difficulty presets existed, all of it on
+	// Normal. Load moves it into Progress.
+	HighScore int `json:"high_score,omitempty"`
+	MaxLevel  int `json:"max_level,omitempty"`
+}
+
+// For returns the progress on difficulty d.
+func (s *SaveData) For(d config.Difficulty) *Progress {
+	if s.Progress == nil {
+		s.Progress = make(map[string]*Progress)
+	}
+	key := strings.ToLower(d.String())
+	p := s.Progress[key]
+	if p == nil {
+		p = &Progress{}
+		s.Progress[key] = p
+	}
+	return p
+}
+
+// Dir returns the directory holding save data and user levels.
+func Dir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "."
@@ -21,7 +52,7 @@ func configDir() string {
 }
 
 func savePath() string {
-	return filepath.Join(configDir(), "save.json")
+	return filepath.Join(Dir(), "save.json")
 }
 
 // Load reads save data from disk.
@@ -34,12 +65,18 @@ func Load() *SaveData {
 	if err := json.Unmarshal(data, &s); err != nil {
 		return &SaveData{}
 	}
+	if s.HighScore != 0 || s.MaxLevel != 0 {
+		p := s.For(config.DifficultyNormal)
+		p.HighScore = max(p.HighScore, s.HighScore)
+		p.MaxLevel = max(p.MaxLevel, s.MaxLevel)
+		s.HighScore, s.MaxLevel = 0, 0
+	}
 	return &s
 }
 
 // Save writes save data to disk.
 func Save(s *SaveData) error {
-	dir := configDir()
+	dir := Dir()
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AchrafSoltani/TankStrike/audio"
+)
+
+// runSFX implements "tankstrike sfx list" and "tankstrike sfx export",
+// which render sound effect definitions to WAV files for auditioning
+// outside the game. It returns the process exit code.
+func runSFX(args []string) int {
+	if len(args) == 0 {
+		sfxUsage()
+		return 2
+	}
+	switch args[0] {
+	case "list":
+		for _, e := range audio.DefaultEffects() {
+			fmt.Printf("%-16s %.2fs\n", e.Name, e.Duration())
+		}
+		return 0
+	case "export":
+		return runSFXExport(args[1:])
+	}
+	sfxUsage()
+	return 2
+}
+
+func sfxUsage() {
+	fmt.Fprintln(os.Stderr, "usage: tankstrike sfx list")
+	fmt.Fprintln(os.Stderr, "       tankstrike sfx export [-o file.wav] [-dir DIR] [-sfx DIR] <name|file.sfx>...")
+}
+
+func runSFXExport(args []string) int {
+	fs := flag.NewFlagSet("sfx export", flag.ExitOnError)
+	out := fs.String("o", "", "WAV file to write (only with a single effect)")
+	dir := fs.String("dir", ".", "directory to write <name>.wav files to")
+	sfxDir := fs.String("sfx", "", "look up effect names in this directory's .sfx files before the built-in ones")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || *out != "" && fs.NArg() > 1 {
+		sfxUsage()
+		return 2
+	}
+	fx := audio.DefaultEffects()
+	if *sfxDir != "" {
+		if err := fx.LoadDir(*sfxDir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	failed := false
+	for _, arg := range fs.Args() {
+		e, err := lookupEffect(fx, arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		path := *out
+		if path == "" {
+			path = filepath.Join(*dir, e.Name+".wav")
+		}
+		if err := exportEffect(e, path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: %.2fs\n", path, e.Duration())
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// lookupEffect returns the effect in the .sfx file arg, or the effect in
+// fx called arg.
+func lookupEffect(fx audio.Effects, arg string) (*audio.Effect, error) {
+	if strings.HasSuffix(arg, audio.EffectFileExt) {
+		return audio.LoadEffectFile(arg)
+	}
+	s, ok := audio.SoundNamed(arg)
+	if !ok {
+		return nil, fmt.Errorf("no effect is called %q; see tankstrike sfx list", arg)
+	}
+	return fx[s], nil
+}
+
+func exportEffect(e *audio.Effect, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := audio.WriteWAV(f, audio.PCM(e.Render()), 1); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,110 @@
+package sim
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+)
+
+// Checksum returns a hash of all gameplay state. Two simulations that
+// have diverged in any way that can affect play return different values.
+func (s *Sim) Checksum() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	put := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	putInt := func(v int) { put(uint64(v)) }
+	putFloat := func(v float64) { put(math.Float64bits(v)) }
+	putBool := func(v bool) {
+		if v {
+			put(1)
+		} else {
+			put(0)
+		}
+	}
+	putTank := func(t *entity.Tank) {
+		putFloat(t.X)
+		putFloat(t.Y)
+		putInt(int(t.Dir))
+		putInt(t.HP)
+		putBool(t.Alive)
+		putBool(t.Moving)
+		putFloat(t.ShootCooldown)
+	}
+
+	putInt(int(s.State))
+	put(s.Tick)
+	putInt(s.Level)
+	putFloat(s.LevelTime)
+	for y := 0; y < config.GridHeight; y++ {
+		for x := 0; x < config.GridWidth; x++ {
+			putInt(int(s.Grid.Tiles[y][x]))
+		}
+	}
+
+	putInt(len(s.Players))
+	for _, p := range s.Players {
+		putTank(&p.Tank)
+		putInt(p.Lives)
+		putInt(p.Score)
+		putInt(p.Stars)
+		putFloat(p.ShieldTimer)
+		putFloat(p.RespawnTimer)
+	}
+
+	if s.Eagle != nil {
+		putBool(s.Eagle.Alive)
+	}
+
+	putInt(len(s.Enemies))
+	for _, e := range s.Enemies {
+		putTank(&e.Tank)
+		putInt(int(e.Type))
+		putFloat(e.DirTimer)
+		putBool(e.Blocked)
+		putBool(e.Breaching)
+		putFloat(e.DetourTimer)
+	}
+	putInt(len(s.Bullets))
+	for _, b := range s.Bullets {
+		putFloat(b.X)
+		putFloat(b.Y)
+		putInt(int(b.Dir))
+		putBool(b.Active)
+		putBool(b.IsPlayer)
+		putInt(b.Owner)
+	}
+	putInt(len(s.PowerUps))
+	for _, p := range s.PowerUps {
+		putFloat(p.X)
+		putFloat(p.Y)
+		putInt(int(p.Type))
+		putBool(p.Active)
+	}
+
+	// A disabled director never changes anything, so matches without one
+	// keep the checksums they had before it existed.
+	if s.Director.Enabled {
+		putFloat(s.Director.Intensity)
+		putFloat(s.Director.Deaths)
+		putFloat(s.Director.Kills)
+	}
+
+	if s.Spawner != nil {
+		putInt(len(s.Spawner.Queue))
+		putFloat(s.Spawner.Timer)
+		putInt(s.Spawner.TotalSpawned)
+	}
+	putFloat(s.ClockTimer)
+	putFloat(s.ShovelTimer)
+
+	if state, err := s.src.MarshalBinary(); err == nil {
+		h.Write(state)
+	}
+	return h.Sum64()
+}
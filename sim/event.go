@@ -0,0 +1,34 @@
+package sim
+
+// EventType identifies something that happened during a tick which a host
+// may want to present (sound, particles, screen shake) or persist.
+type EventType int
+
+const (
+	EventPlayerShoot EventType = iota
+	EventEnemyShoot
+	EventEnemyDestroyed // enemy killed by a bullet
+	EventEnemyBombed    // enemy killed by the Bomb power-up
+	EventPlayerDestroyed
+	EventTileDestroyed   // brick knocked out by a bullet
+	EventBulletDeflected // bullet stopped by steel it could not break
+	EventEagleDestroyed
+	EventPowerUpCollected
+	EventSteelDestroyed // steel knocked out by a fully upgraded bullet
+	EventEnemyHit       // armoured enemy hit without being destroyed
+	EventEnemySpawned
+	EventPowerUpSpawned
+	EventShieldExpiring // a player's shield is about to run out
+	EventFortifyEnded   // the Shovel's steel around the eagle reverted to brick
+	EventPlayerRespawned
+	EventLevelStart
+	EventLevelComplete
+	EventGameOver
+)
+
+// Event is a single simulation event. X and Y are play-area pixel
+// coordinates and are only meaningful for positional events.
+type Event struct {
+	Type EventType
+	X, Y float64
+}
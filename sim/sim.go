@@ -0,0 +1,675 @@
+// Package sim runs the TankStrike rules without any window, audio or
+// rendering dependency. Hosts feed it button state once per tick and read
+// back entity state and the events raised during that tick.
+package sim
+
+import (
+	"math/rand/v2"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// Sim holds the complete state of a match.
+type Sim struct {
+	State     State
+	Grid      *world.Grid
+	Players   []*entity.PlayerTank // one per player, 1P first
+	Eagle     *entity.Eagle
+	Enemies   []*entity.EnemyTank
+	Bullets   []*entity.Bullet
+	PowerUps  []*entity.PowerUp
+	Spawner   *system.Spawner
+	Levels    []*world.Level // campaign being played
+	Level     int            // index into Levels
+	Def       *world.Level   // definition of the current level
+	Time      float64
+	Tick      uint64  // ticks elapsed since Start
+	LevelTime float64 // seconds spent playing the current level
+
+	// Director adjusts the spawner to how the players are doing.
+	Director system.Director
+
+	// Power-up timers
+	ClockTimer  float64 // freeze enemies timer
+	ShovelTimer float64 // fortified eagle timer
+
+	// Level stats
+	KillsBasic  int
+	KillsFast   int
+	KillsPower  int
+	KillsArmour int
+
+	// Transition timers
+	GameOverTimer   float64
+	LevelComplTimer float64
+	LevelIntroTimer float64
+
+	// Events raised by the last call to Update.
+	Events []Event
+
+	// Flow fields enemies steer by. They are derived from the grid and
+	// tank positions, so snapshots leave them out.
+	paths *system.Paths
+
+	// Seed drives every gameplay random draw. Each match started with
+	// the same seed, level and input plays out identically.
+	Seed uint64
+	src  *rand.PCG
+	rng  *rand.Rand
+
+	// Difficulty is the balance preset matches are started with, and
+	// Adaptive whether their director is enabled.
+	Difficulty config.Difficulty
+	Adaptive   bool
+}
+
+// gameplayStream selects the PCG stream used for gameplay draws so that
+// hosts can derive unrelated cosmetic streams from the same seed.
+const gameplayStream = 0x7461_6e6b // "tank"
+
+// NewSim creates an idle simulation with the given seed. Call Start to begin a match.
+func NewSim(seed uint64) *Sim {
+	src := rand.NewPCG(seed, gameplayStream)
+	return &Sim{
+		State:      StateEnded,
+		Levels:     world.Levels,
+		Grid:       world.NewGrid(),
+		Players:    []*entity.PlayerTank{entity.NewPlayerTank(0)},
+		Seed:       seed,
+		src:        src,
+		rng:        rand.New(src),
+		Difficulty: config.DifficultyNormal,
+		paths:      system.NewPaths(),
+	}
+}
+
+// Start begins a new match at the given level with fresh tanks for the
+// given number of players and reseeds the gameplay random source.
+func (s *Sim) Start(level, players int) {
+	s.src.Seed(s.Seed, gameplayStream)
+	players = max(1, min(players, config.MaxPlayers))
+	s.Players = s.Players[:0]
+	for i := 0; i < players; i++ {
+		p := entity.NewPlayerTank(i)
+		p.Lives = s.Difficulty.Preset().StartLives
+		s.Players = append(s.Players, p)
+	}
+	if level < 0 {
+		level = 0
+	}
+	if level >= len(s.Levels) {
+		level = len(s.Levels) - 1
+	}
+	s.Events = s.Events[:0]
+	s.Tick = 0
+	s.Director = system.NewDirector(s.Adaptive)
+	s.startLevel(level)
+}
+
+// Stop abandons the match in progress.
+func (s *Sim) Stop() {
+	s.State = StateEnded
+}
+
+func (s *Sim) startLevel(index int) {
+	if index >= 0 && index < len(s.Levels) {
+		s.Level = index
+		s.Def = s.Levels[index]
+		if err := s.Def.Load(s.Grid); err != nil {
+			// Levels reach the sim parsed by ParseLevel or checked by
+			// the editor, so their layouts are well formed.
+			panic("sim: " + err.Error())
+		}
+		s.LevelTime = 0
+		s.Bullets = s.Bullets[:0]
+		s.Enemies = s.Enemies[:0]
+		s.PowerUps = s.PowerUps[:0]
+		s.ClockTimer = 0
+		s.ShovelTimer = 0
+		s.KillsBasic = 0
+		s.KillsFast = 0
+		s.KillsPower = 0
+		s.KillsArmour = 0
+		s.Spawner = system.NewSpawner(index, s.Def, s.Difficulty.Preset(), s.rng)
+		s.findEagle()
+		s.Director.StartLevel(s.eagleWalls())
+		for _, p := range s.Players {
+			sp := s.Def.PlayerSpawn(p.Index)
+			p.SetSpawn(sp[0], sp[1])
+			if p.Lives > 0 {
+				p.Respawn()
+			}
+		}
+		s.State = StateLevelIntro
+		s.LevelIntroTimer = 2.0
+		s.emit(EventLevelStart, 0, 0)
+	}
+}
+
+// eagleWalls returns how many brick and steel tiles are left in the ring
+// of sub-blocks around the eagle.
+func (s *Sim) eagleWalls() int {
+	if s.Eagle == nil {
+		return 0
+	}
+	ex, ey := int(s.Eagle.X)/config.SubBlock, int(s.Eagle.Y)/config.SubBlock
+	n := 0
+	for y := ey - 1; y <= ey+2; y++ {
+		for x := ex - 1; x <= ex+2; x++ {
+			inside := x >= ex && x < ex+2 && y >= ey && y < ey+2
+			if inside || x < 0 || y < 0 || x >= config.GridWidth || y >= config.GridHeight {
+				continue
+			}
+			if t := s.Grid.Get(x, y); t == world.TileBrick || t == world.TileSteel {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (s *Sim) findEagle() {
+	s.Eagle = nil
+	if x, y := s.Def.Eagle[0], s.Def.Eagle[1]; s.Grid.Get(x, y) == world.TileEagle {
+		s.Eagle = entity.NewEagle(x, y)
+		return
+	}
+	for y := 0; y < config.GridHeight; y++ {
+		for x := 0; x < config.GridWidth; x++ {
+			if s.Grid.Get(x, y) == world.TileEagle {
+				s.Eagle = entity.NewEagle(x, y)
+				return
+			}
+		}
+	}
+}
+
+func (s *Sim) emit(typ EventType, x, y float64) {
+	s.Events = append(s.Events, Event{Type: typ, X: x, Y: y})
+}
+
+// Update advances the match by one tick of dt seconds. inputs[i] is the
+// input of player i; any player may pause, resume or confirm. Hosts should
+// always pass config.TickDuration so that results do not depend on frame
+// rate.
+func (s *Sim) Update(dt float64, inputs []*system.Input) {
+	s.Events = s.Events[:0]
+	s.Time += dt
+	s.Tick++
+	s.storePrevious()
+
+	confirm := anyJustPressed(inputs, system.ButtonConfirm|system.ButtonFire)
+
+	switch s.State {
+	case StateLevelIntro:
+		s.LevelIntroTimer -= dt
+		if s.LevelIntroTimer <= 0 {
+			s.State = StatePlaying
+		}
+	case StatePlaying:
+		s.updatePlaying(dt, inputs)
+		if s.State == StatePlaying && anyJustPressed(inputs, system.ButtonPause) {
+			s.State = StatePaused
+		}
+	case StatePaused:
+		if anyJustPressed(inputs, system.ButtonPause|system.ButtonConfirm) {
+			s.State = StatePlaying
+		}
+	case StateGameOver:
+		s.GameOverTimer -= dt
+		if s.GameOverTimer <= 0 && confirm {
+			s.State = StateEnded
+		}
+	case StateLevelComplete:
+		s.LevelComplTimer -= dt
+		if s.LevelComplTimer <= 0 && confirm {
+			next := s.Level + 1
+			if next < len(s.Levels) {
+				s.startLevel(next)
+			} else {
+				s.State = StateEnded
+			}
+		}
+	}
+}
+
+// anyJustPressed reports whether any player just pressed one of the buttons in b.
+func anyJustPressed(inputs []*system.Input, b system.Button) bool {
+	for _, in := range inputs {
+		if in.IsJustPressed(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// noInput stands in for players the host supplied no input for.
+var noInput = system.NewInput()
+
+// powerUpSize is the side of a power-up's pickup box, in pixels.
+const powerUpSize = 24
+
+func (s *Sim) updatePlaying(dt float64, inputs []*system.Input) {
+	s.LevelTime += dt
+
+	for _, p := range s.Players {
+		in := noInput
+		if p.Index < len(inputs) {
+			in = inputs[p.Index]
+		}
+		p.HandleInput(in.IsDown(system.ButtonUp), in.IsDown(system.ButtonDown),
+			in.IsDown(system.ButtonLeft), in.IsDown(system.ButtonRight))
+		shield, respawning := p.ShieldTimer, p.Respawning
+		p.UpdatePlayer(dt)
+		if respawning && !p.Respawning {
+			s.emit(EventPlayerRespawned, p.CenterX(), p.CenterY())
+		} else if shield > config.ShieldWarning && p.ShieldTimer <= config.ShieldWarning {
+			s.emit(EventShieldExpiring, p.CenterX(), p.CenterY())
+		}
+
+		otherTanks := s.tankBBoxesExcluding(&p.Tank)
+		system.MovePlayerTank(p, s.Grid, dt, otherTanks)
+
+		if in.IsDown(system.ButtonFire) && p.CanShoot() {
+			if system.CountPlayerBullets(s.Bullets, p.Index) < config.MaxPlayerBullets {
+				bx, by := p.Shoot()
+				bullet := entity.NewBullet(bx, by, p.Dir, p.BulletSpeed, p.PowerLevel, true)
+				bullet.Owner = p.Index
+				s.Bullets = append(s.Bullets, bullet)
+				s.emit(EventPlayerShoot, bx, by)
+			}
+		}
+	}
+
+	alive := s.countAliveEnemies()
+	if total := s.Spawner.TotalForLevel; total > 0 {
+		progress := float64(s.Spawner.TotalSpawned-alive) / float64(total)
+		s.Director.Update(dt, s.LevelTime, progress, s.eagleWalls())
+	}
+	if enemy := s.Spawner.Update(dt, alive, &s.Director, s.rng); enemy != nil {
+		s.Enemies = append(s.Enemies, enemy)
+		s.emit(EventEnemySpawned, enemy.CenterX(), enemy.CenterY())
+	}
+
+	frozen := s.ClockTimer > 0
+	ai := system.AIContext{
+		Players:  s.Players,
+		Enemies:  s.Enemies,
+		Eagle:    s.Eagle,
+		Grid:     s.Grid,
+		Paths:    s.paths,
+		Accuracy: s.Spawner.Preset.Accuracy,
+		Dt:       dt,
+		Rng:      s.rng,
+	}
+	for _, e := range s.Enemies {
+		if !e.Alive {
+			continue
+		}
+		if frozen {
+			e.UpdateEnemy(dt) // still animate flash, but don't move/shoot
+			continue
+		}
+		ai.Enemy = e
+		ai.Player = s.nearestPlayer(e.CenterX(), e.CenterY())
+		ai.Others = s.tankBBoxesExcluding(&e.Tank)
+		if system.UpdateEnemyAI(&ai) {
+			bx, by := e.Shoot()
+			bullet := entity.NewBullet(bx, by, e.Dir, e.BulletSpeed, 0, false)
+			s.Bullets = append(s.Bullets, bullet)
+			s.emit(EventEnemyShoot, bx, by)
+		}
+	}
+
+	if s.Eagle != nil {
+		s.Eagle.Update(dt)
+	}
+
+	for _, b := range s.Bullets {
+		b.Update(dt)
+	}
+
+	for _, b := range s.Bullets {
+		impact, hit := system.BulletGridCollision(b, s.Grid)
+		if !hit {
+			continue
+		}
+		switch {
+		case impact.Tile == world.TileEagle:
+			s.emit(EventEagleDestroyed, impact.X, impact.Y)
+		case impact.Destroyed && impact.Tile == world.TileSteel:
+			s.emit(EventSteelDestroyed, impact.X, impact.Y)
+		case impact.Destroyed:
+			s.emit(EventTileDestroyed, impact.X, impact.Y)
+		default:
+			s.emit(EventBulletDeflected, impact.X, impact.Y)
+		}
+	}
+
+	for _, b := range s.Bullets {
+		if !b.Active || !b.IsPlayer {
+			continue
+		}
+		for _, e := range s.Enemies {
+			if !e.Alive {
+				continue
+			}
+			if system.BulletTankCollision(b, &e.Tank) {
+				b.Active = false
+				destroyed := e.Hit(1)
+				if destroyed {
+					s.Players[b.Owner].Score += e.ScoreValue
+					s.emit(EventEnemyDestroyed, e.CenterX(), e.CenterY())
+					s.trackKill(e.Type)
+					if e.HasPowerUp {
+						p := entity.NewPowerUp(s.rng)
+						s.PowerUps = append(s.PowerUps, p)
+						s.emit(EventPowerUpSpawned, p.X+powerUpSize/2, p.Y+powerUpSize/2)
+					}
+				} else {
+					s.emit(EventEnemyHit, e.CenterX(), e.CenterY())
+				}
+				break
+			}
+		}
+	}
+
+	for _, b := range s.Bullets {
+		if !b.Active || b.IsPlayer {
+			continue
+		}
+		for _, p := range s.Players {
+			if p.Alive && !p.IsInvulnerable() && system.BulletTankCollision(b, &p.Tank) {
+				b.Active = false
+				s.emit(EventPlayerDestroyed, p.CenterX(), p.CenterY())
+				p.Die()
+				s.Director.PlayerDied()
+				break
+			}
+		}
+	}
+
+	// Power-up collection
+	for _, pl := range s.Players {
+		if !pl.Alive {
+			continue
+		}
+		for _, p := range s.PowerUps {
+			if !p.Active {
+				continue
+			}
+			// Simple AABB overlap between player and power-up
+			if pl.X < p.X+powerUpSize && pl.X+float64(config.TankSize) > p.X &&
+				pl.Y < p.Y+powerUpSize && pl.Y+float64(config.TankSize) > p.Y {
+				p.Active = false
+				s.emit(EventPowerUpCollected, p.X, p.Y)
+				s.applyPowerUp(pl, p.Type)
+			}
+		}
+	}
+
+	// Update power-ups
+	for _, p := range s.PowerUps {
+		p.Update(dt)
+	}
+
+	// Clock timer (freeze enemies)
+	if s.ClockTimer > 0 {
+		s.ClockTimer -= dt
+	}
+
+	// Shovel timer (fortification)
+	if s.ShovelTimer > 0 {
+		s.ShovelTimer -= dt
+		if s.ShovelTimer <= 0 {
+			s.unfortifyEagle()
+			if s.Eagle != nil {
+				s.emit(EventFortifyEnded, s.Eagle.CenterX(), s.Eagle.CenterY())
+			}
+		}
+	}
+
+	// Clean up power-ups
+	s.cleanPowerUps()
+
+	if s.Eagle != nil {
+		for y := 0; y < config.GridHeight; y++ {
+			for x := 0; x < config.GridWidth; x++ {
+				if s.Grid.Get(x, y) == world.TileEagleDead {
+					s.Eagle.Alive = false
+				}
+			}
+		}
+	}
+
+	if s.Eagle != nil && !s.Eagle.Alive || s.PlayersOut() || s.TimeUp() {
+		s.State = StateGameOver
+		s.GameOverTimer = 2.0
+		s.emit(EventGameOver, 0, 0)
+	}
+
+	if s.Spawner.Done() && s.countAliveEnemies() == 0 {
+		s.State = StateLevelComplete
+		s.LevelComplTimer = 1.5
+		s.emit(EventLevelComplete, 0, 0)
+	}
+
+	s.cleanBullets()
+	s.cleanEnemies()
+
+	// Debug level switching
+	if anyJustPressed(inputs, system.ButtonSkipLevel) {
+		next := s.Level + 1
+		if next < len(s.Levels) {
+			s.startLevel(next)
+		}
+	}
+}
+
+// storePrevious records every moving entity's position at the start of a tick.
+func (s *Sim) storePrevious() {
+	for _, p := range s.Players {
+		p.StorePrevious()
+	}
+	for _, e := range s.Enemies {
+		e.StorePrevious()
+	}
+	for _, b := range s.Bullets {
+		b.StorePrevious()
+	}
+}
+
+func (s *Sim) tankBBoxesExcluding(self *entity.Tank) []system.BBox {
+	boxes := make([]system.BBox, 0, len(s.Enemies)+len(s.Players))
+	for _, p := range s.Players {
+		if p.Alive && &p.Tank != self {
+			boxes = append(boxes, system.TankBBox(&p.Tank))
+		}
+	}
+	for _, e := range s.Enemies {
+		if e.Alive && &e.Tank != self {
+			boxes = append(boxes, system.TankBBox(&e.Tank))
+		}
+	}
+	return boxes
+}
+
+func (s *Sim) countAliveEnemies() int {
+	count := 0
+	for _, e := range s.Enemies {
+		if e.Alive {
+			count++
+		}
+	}
+	return count
+}
+
+// nearestPlayer returns the living player closest to (x, y), or 1P if
+// nobody is alive.
+func (s *Sim) nearestPlayer(x, y float64) *entity.PlayerTank {
+	best := s.Players[0]
+	bestDist := -1.0
+	for _, p := range s.Players {
+		if !p.Alive {
+			continue
+		}
+		dx, dy := p.CenterX()-x, p.CenterY()-y
+		if d := dx*dx + dy*dy; bestDist < 0 || d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	return best
+}
+
+// PlayersOut returns true once every player has lost their last life.
+func (s *Sim) PlayersOut() bool {
+	for _, p := range s.Players {
+		if p.Alive || p.Lives > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Score returns the team score: the sum of every player's score.
+func (s *Sim) Score() int {
+	total := 0
+	for _, p := range s.Players {
+		total += p.Score
+	}
+	return total
+}
+
+// TimeUp returns true once a timed level has run out of time.
+func (s *Sim) TimeUp() bool {
+	return s.Def != nil && s.Def.TimeLimit > 0 && s.LevelTime >= s.Def.TimeLimit
+}
+
+// EnemiesRemaining returns the enemies still to spawn plus those alive on the field.
+func (s *Sim) EnemiesRemaining() int {
+	if s.Spawner == nil {
+		return 0
+	}
+	return s.Spawner.Remaining() + s.countAliveEnemies()
+}
+
+func (s *Sim) cleanBullets() {
+	n := 0
+	for _, b := range s.Bullets {
+		if b.Active {
+			s.Bullets[n] = b
+			n++
+		}
+	}
+	s.Bullets = s.Bullets[:n]
+}
+
+func (s *Sim) trackKill(typ entity.EnemyType) {
+	s.Director.EnemyKilled()
+	switch typ {
+	case entity.EnemyBasic:
+		s.KillsBasic++
+	case entity.EnemyFast:
+		s.KillsFast++
+	case entity.EnemyPower:
+		s.KillsPower++
+	case entity.EnemyArmour:
+		s.KillsArmour++
+	}
+}
+
+func (s *Sim) cleanPowerUps() {
+	n := 0
+	for _, p := range s.PowerUps {
+		if p.Active {
+			s.PowerUps[n] = p
+			n++
+		}
+	}
+	s.PowerUps = s.PowerUps[:n]
+}
+
+// applyPowerUp gives the power-up collected by p its effect.
+func (s *Sim) applyPowerUp(p *entity.PlayerTank, typ entity.PowerUpType) {
+	switch typ {
+	case entity.PowerUpStar:
+		p.ApplyStar()
+	case entity.PowerUpTank:
+		p.Lives++
+	case entity.PowerUpHelmet:
+		p.ShieldTimer = config.PowerUpDuration
+	case entity.PowerUpShovel:
+		if s.Eagle != nil {
+			s.fortifyEagle()
+			s.ShovelTimer = config.PowerUpDuration
+		}
+	case entity.PowerUpBomb:
+		for _, e := range s.Enemies {
+			if e.Alive {
+				e.Alive = false
+				p.Score += e.ScoreValue
+				s.emit(EventEnemyBombed, e.CenterX(), e.CenterY())
+				s.Director.EnemyKilled()
+			}
+		}
+	case entity.PowerUpClock:
+		s.ClockTimer = config.PowerUpDuration
+	}
+	p.Score += 500
+}
+
+func (s *Sim) fortifyEagle() {
+	if s.Eagle == nil {
+		return
+	}
+	// Replace brick around eagle with steel
+	ex := int(s.Eagle.X) / config.SubBlock
+	ey := int(s.Eagle.Y) / config.SubBlock
+	for dy := -1; dy <= 2; dy++ {
+		for dx := -1; dx <= 2; dx++ {
+			gx, gy := ex+dx, ey+dy
+			tile := s.Grid.Get(gx, gy)
+			if tile == world.TileBrick || tile == world.TileEmpty {
+				// Only fortify the border cells
+				if dx == -1 || dx == 2 || dy == -1 || dy == 2 {
+					s.Grid.Set(gx, gy, world.TileSteel)
+				}
+			}
+		}
+	}
+	s.Eagle.Fortified = true
+}
+
+func (s *Sim) unfortifyEagle() {
+	if s.Eagle == nil {
+		return
+	}
+	ex := int(s.Eagle.X) / config.SubBlock
+	ey := int(s.Eagle.Y) / config.SubBlock
+	for dy := -1; dy <= 2; dy++ {
+		for dx := -1; dx <= 2; dx++ {
+			gx, gy := ex+dx, ey+dy
+			tile := s.Grid.Get(gx, gy)
+			if tile == world.TileSteel {
+				if dx == -1 || dx == 2 || dy == -1 || dy == 2 {
+					s.Grid.Set(gx, gy, world.TileBrick)
+				}
+			}
+		}
+	}
+	s.Eagle.Fortified = false
+}
+
+func (s *Sim) cleanEnemies() {
+	n := 0
+	for _, e := range s.Enemies {
+		if e.Alive {
+			s.Enemies[n] = e
+			n++
+		}
+	}
+	s.Enemies = s.Enemies[:n]
+}
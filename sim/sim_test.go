@@ -0,0 +1,50 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+func TestShovel(t *testing.T) {
+	tests := []struct {
+		name  string
+		eagle bool
+	}{
+		{"with eagle", true},
+		{"without eagle", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSim(1)
+			s.Start(0, 1)
+			inputs := []*system.Input{system.NewInput()}
+			for s.State != StatePlaying {
+				s.Update(config.TickDuration, inputs)
+			}
+			if !tt.eagle {
+				s.Eagle = nil
+			}
+			s.applyPowerUp(s.Players[0], entity.PowerUpShovel)
+
+			ended := 0
+			for range int((config.PowerUpDuration + 1) * config.TickRate) {
+				s.Update(config.TickDuration, inputs)
+				for _, ev := range s.Events {
+					if ev.Type == EventFortifyEnded {
+						ended++
+					}
+				}
+			}
+			want := 0
+			if tt.eagle {
+				want = 1
+			}
+			if ended != want {
+				t.Errorf("fortification ended %d times, want %d", ended, want)
+			}
+		})
+	}
+}
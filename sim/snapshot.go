@@ -0,0 +1,98 @@
+package sim
+
+import (
+	"math/rand/v2"
+	"slices"
+
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// Snapshot is a copy of all gameplay state, taken by Save and put back by
+// Restore. A Snapshot keeps its buffers between saves, so saving into the
+// same one every tick stops allocating once it has grown to fit.
+type Snapshot struct {
+	sim      Sim // the Sim's own fields; its pointers and slices are not used
+	grid     world.Grid
+	players  []entity.PlayerTank
+	eagle    entity.Eagle
+	hasEagle bool
+	enemies  []entity.EnemyTank
+	bullets  []entity.Bullet
+	powerUps []entity.PowerUp
+	spawner  system.Spawner
+	queue    []entity.EnemyType // spawner.Queue
+	pcg      rand.PCG
+}
+
+// Tick returns the tick the snapshot was taken at.
+func (snap *Snapshot) Tick() uint64 {
+	return snap.sim.Tick
+}
+
+// Save copies the state of the match into snap.
+func (s *Sim) Save(snap *Snapshot) {
+	snap.sim = *s
+	snap.grid = *s.Grid
+	snap.players = saveAll(snap.players, s.Players)
+	snap.hasEagle = s.Eagle != nil
+	if snap.hasEagle {
+		snap.eagle = *s.Eagle
+	}
+	snap.enemies = saveAll(snap.enemies, s.Enemies)
+	snap.bullets = saveAll(snap.bullets, s.Bullets)
+	snap.powerUps = saveAll(snap.powerUps, s.PowerUps)
+	if s.Spawner != nil {
+		snap.spawner = *s.Spawner
+		snap.queue = append(snap.queue[:0], s.Spawner.Queue...)
+	}
+	snap.pcg = *s.src
+}
+
+// Restore puts the match back into the state saved in snap. Entities are
+// replaced rather than modified, so pointers taken before the call keep
+// describing the abandoned state. Events are cleared.
+func (s *Sim) Restore(snap *Snapshot) {
+	grid, levels, events, src, r := s.Grid, s.Levels, s.Events, s.src, s.rng
+	paths := s.paths
+	*s = snap.sim
+	s.Grid, s.Levels, s.Events, s.src, s.rng = grid, levels, events[:0], src, r
+	s.paths = paths
+
+	*s.Grid = snap.grid
+	s.Players = restoreAll(snap.players)
+	s.Eagle = nil
+	if snap.hasEagle {
+		eagle := snap.eagle
+		s.Eagle = &eagle
+	}
+	s.Enemies = restoreAll(snap.enemies)
+	s.Bullets = restoreAll(snap.bullets)
+	s.PowerUps = restoreAll(snap.powerUps)
+	if snap.sim.Spawner != nil {
+		spawner := snap.spawner
+		spawner.Queue = slices.Clone(snap.queue)
+		s.Spawner = &spawner
+	}
+	*s.src = snap.pcg
+}
+
+func saveAll[T any](dst []T, src []*T) []T {
+	dst = dst[:0]
+	for _, v := range src {
+		dst = append(dst, *v)
+	}
+	return dst
+}
+
+// restoreAll copies src into a single allocation and returns pointers
+// into it.
+func restoreAll[T any](src []T) []*T {
+	vals := slices.Clone(src)
+	ptrs := make([]*T, len(vals))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	return ptrs
+}
@@ -0,0 +1,13 @@
+package sim
+
+// State represents the current phase of a match.
+type State int
+
+const (
+	StateLevelIntro State = iota
+	StatePlaying
+	StatePaused
+	StateGameOver
+	StateLevelComplete
+	StateEnded // match finished; the host should return to its menu
+)
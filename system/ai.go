@@ -1,73 +1,77 @@
 package system
 
 import (
+	_ "embed"
 	"math"
-	"math/rand"
 
 	"github.com/AchrafSoltani/TankStrike/config"
 	"github.com/AchrafSoltani/TankStrike/entity"
 	"github.com/AchrafSoltani/TankStrike/world"
 )
 
-// UpdateEnemyAI updates the AI for a single enemy tank.
-func UpdateEnemyAI(e *entity.EnemyTank, grid *world.Grid, dt float64,
-	playerX, playerY float64, eagleX, eagleY float64,
-	otherTanks []BBox) {
+//go:embed personalities.ai
+var builtinPersonalities []byte
 
-	if !e.Alive {
-		return
-	}
-
-	e.UpdateEnemy(dt)
+// Personalities holds the behaviour tree each enemy type runs every tick,
+// embedded from personalities.ai. Each tree drives and shoots in
+// parallel. Trees are built from the reusable nodes in behavior.go, so a
+// new type of tank only needs an entry in the file.
+var Personalities = mustParsePersonalities()
 
-	// Direction timer
-	e.DirTimer -= dt
-	moved := MoveTank(&e.Tank, grid, dt, otherTanks)
-
-	// If blocked or timer expired, pick new direction
-	if !moved || e.DirTimer <= 0 {
-		pickNewDirection(e, playerX, playerY, eagleX, eagleY)
-		e.DirTimer = config.AIDirectionMinTime +
-			rand.Float64()*(config.AIDirectionMaxTime-config.AIDirectionMinTime)
+func mustParsePersonalities() map[entity.EnemyType]Node {
+	trees, err := ParsePersonalities(builtinPersonalities)
+	if err != nil {
+		panic("system: built-in personalities.ai: " + err.Error())
 	}
+	return trees
 }
 
-// ShouldShoot returns whether the enemy should fire this frame.
-func ShouldShoot(e *entity.EnemyTank, dt float64) bool {
-	if !e.CanShoot() {
+// UpdateEnemyAI runs the behaviour tree of c.Enemy's type for one tick,
+// moves the tank, and returns whether it should fire.
+func UpdateEnemyAI(c *AIContext) bool {
+	e := c.Enemy
+	if !e.Alive {
 		return false
 	}
-	return rand.Float64() < e.ShootChance*dt
-}
 
-func pickNewDirection(e *entity.EnemyTank, playerX, playerY, eagleX, eagleY float64) {
-	roll := rand.Float64()
-	if roll < 0.4 {
-		// Random direction
-		dirs := []entity.Direction{entity.DirUp, entity.DirDown, entity.DirLeft, entity.DirRight}
-		e.Dir = dirs[rand.Intn(4)]
-	} else if roll < 0.7 {
-		// Toward player
-		e.Dir = directionToward(e.CenterX(), e.CenterY(), playerX, playerY)
-	} else {
-		// Toward eagle
-		e.Dir = directionToward(e.CenterX(), e.CenterY(), eagleX, eagleY)
+	e.UpdateEnemy(c.Dt)
+
+	tree, ok := Personalities[e.Type]
+	if !ok {
+		tree = Personalities[entity.EnemyBasic]
 	}
-	e.Moving = true
+	c.fire = false
+	tree.Run(c)
+
+	moved := MoveTank(&e.Tank, c.Grid, c.Dt, c.Others)
+	e.Blocked = e.Moving && !moved
+	e.Breaching = e.Blocked && breachableAhead(&e.Tank, c.Grid)
+	return c.fire && e.CanShoot()
 }
 
-func directionToward(fromX, fromY, toX, toY float64) entity.Direction {
-	dx := toX - fromX
-	dy := toY - fromY
+// atSubBlock reports whether a tank moving at its speed for dt is as close
+// as it will get to the sub-block grid, and if so at which footprint
+// position.
+func atSubBlock(t *entity.Tank, dt float64) (int, int, bool) {
+	sb := float64(config.SubBlock)
+	half := t.Speed * dt / 2
+	x, y := math.Round(t.X/sb), math.Round(t.Y/sb)
+	if math.Abs(t.X-x*sb) > half || math.Abs(t.Y-y*sb) > half {
+		return 0, 0, false
+	}
+	return int(x), int(y), true
+}
 
-	if math.Abs(dx) > math.Abs(dy) {
-		if dx > 0 {
-			return entity.DirRight
+// breachableAhead reports whether the tiles directly in front of a tank
+// include something its shots can clear: brick or the eagle.
+func breachableAhead(t *entity.Tank, grid *world.Grid) bool {
+	x := int(math.Round(t.X / config.SubBlock))
+	y := int(math.Round(t.Y / config.SubBlock))
+	for _, c := range leadingEdge(x, y, t.Dir) {
+		switch grid.Get(c[0], c[1]) {
+		case world.TileBrick, world.TileEagle:
+			return true
 		}
-		return entity.DirLeft
-	}
-	if dy > 0 {
-		return entity.DirDown
 	}
-	return entity.DirUp
+	return false
 }
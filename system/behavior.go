@@ -0,0 +1,353 @@
+package system
+
+import (
+	"math/rand/v2"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// Status is the outcome of running a behaviour node for one tick.
+type Status int
+
+const (
+	StatusFailure Status = iota // the node does not apply
+	StatusSuccess               // the node applies and is done
+	StatusRunning               // the node applies and carries on next tick
+)
+
+// Node is one node of an enemy behaviour tree. Nodes are plain values
+// with no state of their own: anything a behaviour has to remember between
+// ticks lives on the enemy, so one tree can drive every tank of a type.
+type Node interface {
+	Run(c *AIContext) Status
+}
+
+// Target is something an enemy can aim or drive at.
+type Target int
+
+const (
+	TargetPlayer Target = iota // any player; routes lead to the nearest
+	TargetEagle
+)
+
+// AIContext is what a behaviour tree sees of the match while it decides
+// for one enemy.
+type AIContext struct {
+	Enemy    *entity.EnemyTank
+	Player   *entity.PlayerTank // nearest player
+	Players  []*entity.PlayerTank
+	Enemies  []*entity.EnemyTank
+	Eagle    *entity.Eagle
+	Grid     *world.Grid
+	Paths    *Paths
+	Others   []BBox  // every other tank
+	Accuracy float64 // 0-1, how reliably enemies aim
+	Dt       float64
+	Rng      *rand.Rand
+
+	fire bool
+}
+
+// shot traces the bullet the enemy would fire in direction d.
+func (c *AIContext) shot(d entity.Direction) Shot {
+	return CastShot(&c.Enemy.Tank, d, c.Grid, c.Players, c.Enemies)
+}
+
+// box returns the pixel bounds of a target, or false if it is gone.
+func (c *AIContext) box(t Target) (BBox, bool) {
+	switch t {
+	case TargetPlayer:
+		if p := c.Player; p != nil && p.Alive {
+			return TankBBox(&p.Tank), true
+		}
+	case TargetEagle:
+		if e := c.Eagle; e != nil && e.Alive {
+			return BBox{X: e.X, Y: e.Y, W: config.TankSize, H: config.TankSize}, true
+		}
+	}
+	return BBox{}, false
+}
+
+// --- Composites ---
+
+// Selector runs its children in order until one does not fail.
+type Selector []Node
+
+func (s Selector) Run(c *AIContext) Status {
+	for _, n := range s {
+		if st := n.Run(c); st != StatusFailure {
+			return st
+		}
+	}
+	return StatusFailure
+}
+
+// Sequence runs its children in order until one does not succeed.
+type Sequence []Node
+
+func (s Sequence) Run(c *AIContext) Status {
+	for _, n := range s {
+		if st := n.Run(c); st != StatusSuccess {
+			return st
+		}
+	}
+	return StatusSuccess
+}
+
+// Parallel runs every child, so that independent concerns such as
+// driving and shooting can each pick their own branch. It fails only if
+// every child fails.
+type Parallel []Node
+
+func (p Parallel) Run(c *AIContext) Status {
+	st := StatusFailure
+	for _, n := range p {
+		st = max(st, n.Run(c))
+	}
+	return st
+}
+
+// --- Conditions ---
+
+// Breaching succeeds while the enemy is blocked by something its shots
+// can clear.
+type Breaching struct{}
+
+func (Breaching) Run(c *AIContext) Status {
+	return check(c.Enemy.Breaching)
+}
+
+// Loaded succeeds when the enemy can fire.
+type Loaded struct{}
+
+func (Loaded) Run(c *AIContext) Status {
+	return check(c.Enemy.CanShoot())
+}
+
+// RandomShot succeeds at random, with the enemy's ShootChance per second.
+type RandomShot struct{}
+
+func (RandomShot) Run(c *AIContext) Status {
+	return check(c.Rng.Float64() < c.Enemy.ShootChance*c.Dt)
+}
+
+// ClearShot succeeds when the enemy could hit the target by turning to
+// face it: the target is in line with it and first in the way.
+type ClearShot struct {
+	Target Target
+}
+
+func (n ClearShot) Run(c *AIContext) Status {
+	_, ok := lineTo(c, n.Target)
+	return check(ok)
+}
+
+// InSights succeeds when a shot fired now would hit the target first.
+type InSights struct {
+	Target Target
+}
+
+func (n InSights) Run(c *AIContext) Status {
+	return check(c.shot(c.Enemy.Dir).Sight == n.Target.sight())
+}
+
+// Useful succeeds when a shot fired now would hit a player, the eagle or
+// brick, rather than steel, another enemy or nothing at all.
+type Useful struct{}
+
+func (Useful) Run(c *AIContext) Status {
+	switch c.shot(c.Enemy.Dir).Sight {
+	case SightPlayer, SightEagle, SightBrick:
+		return StatusSuccess
+	}
+	return StatusFailure
+}
+
+// Steady succeeds at random, at up to AIAimRate times a second scaled by
+// the enemy's accuracy. Placed before an aimed shot, it is how long the
+// enemy takes to react to a target in its sights.
+type Steady struct{}
+
+func (Steady) Run(c *AIContext) Status {
+	return check(c.Rng.Float64() < c.Accuracy*config.AIAimRate*c.Dt)
+}
+
+// Sloppy succeeds at random, more often the less accurate the enemy is.
+// It lets inaccurate enemies take shots a careful one would not.
+type Sloppy struct{}
+
+func (Sloppy) Run(c *AIContext) Status {
+	return check(c.Rng.Float64() >= c.Accuracy)
+}
+
+func check(ok bool) Status {
+	if ok {
+		return StatusSuccess
+	}
+	return StatusFailure
+}
+
+// --- Actions ---
+
+// Fire shoots if the enemy is loaded.
+type Fire struct{}
+
+func (Fire) Run(c *AIContext) Status {
+	if !c.Enemy.CanShoot() {
+		return StatusFailure
+	}
+	c.fire = true
+	return StatusSuccess
+}
+
+// Face turns the enemy toward a target it has a clear shot at.
+type Face struct {
+	Target Target
+}
+
+func (n Face) Run(c *AIContext) Status {
+	dir, ok := lineTo(c, n.Target)
+	if !ok {
+		return StatusFailure
+	}
+	c.Enemy.Dir = dir
+	return StatusSuccess
+}
+
+// Hold stops the enemy where it is.
+type Hold struct{}
+
+func (Hold) Run(c *AIContext) Status {
+	c.Enemy.Moving = false
+	return StatusSuccess
+}
+
+// Route drives along the cheapest route to a target. It succeeds on
+// arrival and fails if the target is gone or out of reach.
+type Route struct {
+	Target Target
+}
+
+func (n Route) Run(c *AIContext) Status {
+	b, ok := c.box(n.Target)
+	if !ok {
+		return StatusFailure
+	}
+	return follow(c, Footprint(b.X, b.Y))
+}
+
+// Flank drives to a spot Dist sub-blocks to the side of the nearest
+// player, square to the way it is facing, on whichever side the enemy
+// already is. From there the enemy has a shot at the player that the
+// player cannot return without turning.
+type Flank struct {
+	Dist int
+}
+
+func (n Flank) Run(c *AIContext) Status {
+	p := c.Player
+	if p == nil || !p.Alive {
+		return StatusFailure
+	}
+	goal := Footprint(p.X, p.Y)
+	axis, lim, from := 0, fieldW-1, c.Enemy.X-p.X
+	if p.Dir == entity.DirLeft || p.Dir == entity.DirRight {
+		axis, lim, from = 1, fieldH-1, c.Enemy.Y-p.Y
+	}
+	if from < 0 {
+		goal[axis] -= n.Dist
+	} else {
+		goal[axis] += n.Dist
+	}
+	goal[axis] = max(0, min(goal[axis], lim))
+	return follow(c, goal)
+}
+
+// Patrol drives about, turning at random every so often and whenever
+// something is in the way. EagleBias is the chance of each turn being
+// onto the route to the eagle instead.
+type Patrol struct {
+	EagleBias float64
+}
+
+func (n Patrol) Run(c *AIContext) Status {
+	e := c.Enemy
+	e.DirTimer -= c.Dt
+	if e.DirTimer <= 0 || e.Blocked {
+		e.DirTimer = e.DirInterval * (0.5 + c.Rng.Float64())
+		e.Dir = entity.AllDirections[c.Rng.IntN(4)]
+		if b, ok := c.box(TargetEagle); ok && c.Rng.Float64() < n.EagleBias {
+			pos := Footprint(e.X, e.Y)
+			if dir, ok := c.Paths.To(c.Grid, Footprint(b.X, b.Y)).Next(pos[0], pos[1]); ok {
+				e.Dir = dir
+			}
+		}
+	}
+	e.Moving = true
+	return StatusRunning
+}
+
+// Detour takes over while the enemy is stuck against something it cannot
+// shoot through, such as steel, water or another tank: it drives off in a
+// random direction for a while before giving the rest of the tree another
+// go.
+type Detour struct{}
+
+func (Detour) Run(c *AIContext) Status {
+	e := c.Enemy
+	if e.Blocked && !e.Breaching {
+		e.Dir = entity.AllDirections[c.Rng.IntN(4)]
+		e.DetourTimer = config.AIDirectionMinTime +
+			c.Rng.Float64()*(config.AIDirectionMaxTime-config.AIDirectionMinTime)
+	}
+	if e.DetourTimer <= 0 {
+		return StatusFailure
+	}
+	e.DetourTimer -= c.Dt
+	e.Moving = true
+	return StatusRunning
+}
+
+// follow drives along the flow field to goal, turning onto the route at
+// each sub-block crossing.
+func follow(c *AIContext, goal [2]int) Status {
+	e := c.Enemy
+	field := c.Paths.To(c.Grid, goal)
+	pos := Footprint(e.X, e.Y)
+	switch field.CostAt(pos[0], pos[1]) {
+	case Unreachable:
+		return StatusFailure
+	case 0:
+		e.Moving = false
+		return StatusSuccess
+	}
+	if x, y, ok := atSubBlock(&e.Tank, c.Dt); ok {
+		if dir, ok := field.Next(x, y); ok {
+			e.Dir = dir
+		}
+	}
+	e.Moving = true
+	return StatusRunning
+}
+
+// lineTo returns the direction the enemy would have to face for its shot
+// to hit a target first, if there is one.
+func lineTo(c *AIContext, t Target) (entity.Direction, bool) {
+	want := t.sight()
+	for _, d := range entity.AllDirections {
+		if c.shot(d).Sight == want {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// sight returns what a shot at the target hits.
+func (t Target) sight() Sight {
+	if t == TargetEagle {
+		return SightEagle
+	}
+	return SightPlayer
+}
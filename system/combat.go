@@ -5,15 +5,21 @@ import (
 
 	"github.com/AchrafSoltani/TankStrike/config"
 	"github.com/AchrafSoltani/TankStrike/entity"
-	"github.com/AchrafSoltani/TankStrike/render"
 	"github.com/AchrafSoltani/TankStrike/world"
 )
 
+// GridImpact describes a bullet striking a tile.
+type GridImpact struct {
+	Tile      world.TileType // tile that was struck
+	Destroyed bool           // whether the tile was destroyed by the hit
+	X, Y      float64        // pixel centre of the struck sub-block
+}
+
 // BulletGridCollision checks bullet-to-grid collisions and destroys tiles.
-// Returns true if the bullet was consumed.
-func BulletGridCollision(b *entity.Bullet, grid *world.Grid, particles *render.ParticlePool) bool {
+// Returns the impact and true if the bullet was consumed.
+func BulletGridCollision(b *entity.Bullet, grid *world.Grid) (GridImpact, bool) {
 	if !b.Active {
-		return false
+		return GridImpact{}, false
 	}
 
 	// Find the sub-block the bullet centre is in
@@ -22,42 +28,23 @@ func BulletGridCollision(b *entity.Bullet, grid *world.Grid, particles *render.P
 
 	tile := grid.Get(bx, by)
 	if !tile.BlocksBullets() {
-		return false
+		return GridImpact{}, false
 	}
 
-	switch tile {
-	case world.TileBrick:
-		grid.Destroy(bx, by, b.Power)
-		cx := float64(bx*config.SubBlock) + float64(config.SubBlock)/2
-		cy := float64(by*config.SubBlock) + float64(config.SubBlock)/2
-		particles.SpawnDebris(cx, cy)
-		b.Active = false
-		return true
-
-	case world.TileSteel:
-		if b.Power >= 3 {
-			grid.Destroy(bx, by, b.Power)
-			cx := float64(bx*config.SubBlock) + float64(config.SubBlock)/2
-			cy := float64(by*config.SubBlock) + float64(config.SubBlock)/2
-			particles.SpawnDebris(cx, cy)
-		} else {
-			cx := float64(bx*config.SubBlock) + float64(config.SubBlock)/2
-			cy := float64(by*config.SubBlock) + float64(config.SubBlock)/2
-			particles.SpawnSpark(cx, cy)
-		}
-		b.Active = false
-		return true
+	impact := GridImpact{
+		Tile: tile,
+		X:    float64(bx*config.SubBlock) + float64(config.SubBlock)/2,
+		Y:    float64(by*config.SubBlock) + float64(config.SubBlock)/2,
+	}
 
-	case world.TileEagle:
-		grid.Destroy(bx, by, b.Power)
-		cx := float64(bx*config.SubBlock) + float64(config.SubBlock)/2
-		cy := float64(by*config.SubBlock) + float64(config.SubBlock)/2
-		particles.SpawnExplosion(cx, cy, 40)
+	switch tile {
+	case world.TileBrick, world.TileSteel, world.TileEagle:
+		impact.Destroyed = grid.Destroy(bx, by, b.Power)
 		b.Active = false
-		return true
+		return impact, true
 	}
 
-	return false
+	return GridImpact{}, false
 }
 
 // BulletTankCollision checks if a bullet hits a tank. Returns true if hit.
@@ -72,11 +59,11 @@ func BulletTankCollision(b *entity.Bullet, t *entity.Tank) bool {
 	return boxOverlap(bBox, tBox)
 }
 
-// CountPlayerBullets counts active player bullets.
-func CountPlayerBullets(bullets []*entity.Bullet) int {
+// CountPlayerBullets counts the active bullets fired by player owner.
+func CountPlayerBullets(bullets []*entity.Bullet, owner int) int {
 	count := 0
 	for _, b := range bullets {
-		if b.Active && b.IsPlayer {
+		if b.Active && b.IsPlayer && b.Owner == owner {
 			count++
 		}
 	}
@@ -0,0 +1,147 @@
+package system
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/AchrafSoltani/TankStrike/entity"
+)
+
+const (
+	// directorRate is the most the intensity changes per second, so the
+	// director leans on the players gradually rather than lurching.
+	directorRate = 0.05
+
+	// directorMemory is roughly how many seconds deaths and kills stay on
+	// the director's mind.
+	directorMemory = 60.0
+
+	// parLevelTime is how many seconds a level takes a player who is
+	// keeping up; parKillRate is how many enemies they destroy a minute.
+	parLevelTime = 150.0
+	parKillRate  = 8.0
+
+	// Bounds of the director's adjustments at full intensity.
+	directorPacing  = 0.3 // spawn interval shrinks or grows by this fraction
+	directorPromote = 0.4 // chance of a spawn moving one type up or down
+	directorDrops   = 2   // enemies more or fewer between power-up carriers
+	minActive       = 2
+	minPowerUpEvery = 2
+)
+
+// Director watches how the players are doing and adjusts the spawner's
+// pacing, enemy mix and power-up frequency mid-level, so that players who
+// are struggling get some room and players who are cruising get pushed.
+//
+// Intensity runs from -1, easing off, to 1, pushing hard. At 0 the
+// difficulty preset plays as is, which is also all a disabled director
+// ever does.
+type Director struct {
+	Enabled   bool
+	Intensity float64
+	Target    float64 // intensity the director is moving toward
+
+	// What the director has seen. Deaths and Kills fade over about
+	// directorMemory seconds; Pace and Walls are for the current level.
+	Deaths float64
+	Kills  float64
+	Pace   float64 // -1 to 1, how far ahead of par the level is going
+	Walls  float64 // 0 to 1, share of the eagle's walls still standing
+
+	wallsAtStart int
+}
+
+// NewDirector returns a director for a new match that starts out
+// assuming the players are keeping up.
+func NewDirector(enabled bool) Director {
+	return Director{
+		Enabled: enabled,
+		Kills:   parKillRate * directorMemory / 60,
+		Walls:   1,
+	}
+}
+
+// StartLevel resets what the director knows about the level, given how
+// many wall tiles the eagle starts with. Intensity carries over.
+func (d *Director) StartLevel(walls int) {
+	d.wallsAtStart = walls
+	d.Pace = 0
+	d.Walls = 1
+}
+
+// PlayerDied records a player losing a life.
+func (d *Director) PlayerDied() {
+	d.Deaths++
+}
+
+// EnemyKilled records an enemy being destroyed.
+func (d *Director) EnemyKilled() {
+	d.Kills++
+}
+
+// KillRate returns roughly how many enemies the players have been
+// destroying a minute.
+func (d *Director) KillRate() float64 {
+	return d.Kills * 60 / directorMemory
+}
+
+// Update lets the director take stock: levelTime is how long the level
+// has been played, progress the share of its enemies destroyed, and walls
+// how many wall tiles the eagle has left.
+func (d *Director) Update(dt, levelTime, progress float64, walls int) {
+	fade := dt / directorMemory
+	d.Deaths -= d.Deaths * fade
+	d.Kills -= d.Kills * fade
+
+	d.Pace = clamp(2*(progress-levelTime/parLevelTime), -1, 1)
+	d.Walls = 1
+	if d.wallsAtStart > 0 {
+		d.Walls = math.Min(1, float64(walls)/float64(d.wallsAtStart))
+	}
+
+	// Killing quickly and staying ahead of par push the intensity up;
+	// deaths and a breached eagle only ever pull it down.
+	kills := clamp(d.KillRate()/parKillRate-1, -1, 1)
+	deaths := -math.Min(d.Deaths, 2) / 2
+	breach := clamp(2*(d.Walls-1), -1, 0)
+	d.Target = clamp(0.3*kills+0.3*d.Pace+0.6*deaths+0.5*breach, -1, 1)
+
+	if !d.Enabled {
+		return
+	}
+	step := directorRate * dt
+	d.Intensity += clamp(d.Target-d.Intensity, -step, step)
+}
+
+// SpawnInterval returns the time between spawns, given the preset's.
+func (d *Director) SpawnInterval(base float64) float64 {
+	return base * (1 - directorPacing*d.Intensity)
+}
+
+// MaxActive returns how many enemies may be on the field, given the
+// preset's limit: one more or fewer once the intensity passes one half.
+func (d *Director) MaxActive(base int) int {
+	return max(minActive, base+int(math.Round(d.Intensity)))
+}
+
+// PowerUpEvery returns how many enemies there are per power-up carrier,
+// given the preset's count.
+func (d *Director) PowerUpEvery(base int) int {
+	return max(minPowerUpEvery, base+int(math.Round(directorDrops*d.Intensity)))
+}
+
+// Mix returns the type to spawn in place of typ: now and then one type
+// tougher when the director is pushing, one weaker when it is easing off.
+func (d *Director) Mix(typ entity.EnemyType, rng *rand.Rand) entity.EnemyType {
+	if d.Intensity == 0 || rng.Float64() >= directorPromote*math.Abs(d.Intensity) {
+		return typ
+	}
+	if d.Intensity > 0 {
+		return min(typ+1, entity.EnemyArmour)
+	}
+	return max(typ-1, entity.EnemyBasic)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(v, hi))
+}
@@ -1,52 +1,53 @@
 package system
 
-import "github.com/AchrafSoltani/glow"
+// Button is a bit flag for an abstract game command. Hosts map their own
+// devices (keyboard, network, replay file) onto buttons so the simulation
+// never sees a concrete key code.
+type Button uint16
 
-// Input tracks keyboard state.
+const (
+	ButtonUp Button = 1 << iota
+	ButtonDown
+	ButtonLeft
+	ButtonRight
+	ButtonFire
+	ButtonPause
+	ButtonConfirm
+	ButtonSkipLevel // debug: jump to the next level
+	ButtonMute
+	ButtonVolumeUp
+	ButtonVolumeDown
+	ButtonMusicUp
+	ButtonMusicDown
+)
+
+// HostButtons are handled by the host application and never affect the
+// simulation, so they are left out of recordings and network input.
+const HostButtons = ButtonMute | ButtonVolumeUp | ButtonVolumeDown | ButtonMusicUp | ButtonMusicDown
+
+// Input tracks button state between ticks.
 type Input struct {
-	Keys     map[glow.Key]bool
-	JustDown map[glow.Key]bool // true only on the frame the key was first pressed
-	prev     map[glow.Key]bool
+	Held     Button
+	JustDown Button // set only on the tick a button was first pressed
 }
 
 // NewInput creates a new input tracker.
 func NewInput() *Input {
-	return &Input{
-		Keys:     make(map[glow.Key]bool),
-		JustDown: make(map[glow.Key]bool),
-		prev:     make(map[glow.Key]bool),
-	}
-}
-
-// KeyDown registers a key press.
-func (inp *Input) KeyDown(key glow.Key) {
-	inp.Keys[key] = true
+	return &Input{}
 }
 
-// KeyUp registers a key release.
-func (inp *Input) KeyUp(key glow.Key) {
-	inp.Keys[key] = false
+// Update should be called once per tick with the buttons currently held.
+func (inp *Input) Update(held Button) {
+	inp.JustDown = held &^ inp.Held
+	inp.Held = held
 }
 
-// Update should be called once per frame to compute JustDown.
-func (inp *Input) Update() {
-	for k := range inp.JustDown {
-		delete(inp.JustDown, k)
-	}
-	for k, v := range inp.Keys {
-		if v && !inp.prev[k] {
-			inp.JustDown[k] = true
-		}
-	}
-	for k := range inp.prev {
-		delete(inp.prev, k)
-	}
-	for k, v := range inp.Keys {
-		inp.prev[k] = v
-	}
+// IsDown returns true while any of the given buttons is held.
+func (inp *Input) IsDown(b Button) bool {
+	return inp.Held&b != 0
 }
 
-// IsJustPressed returns true only on the first frame a key is held.
-func (inp *Input) IsJustPressed(key glow.Key) bool {
-	return inp.JustDown[key]
+// IsJustPressed returns true only on the first tick any of the given buttons is held.
+func (inp *Input) IsJustPressed(b Button) bool {
+	return inp.JustDown&b != 0
 }
@@ -0,0 +1,245 @@
+package system
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+const (
+	// Unreachable is the cost of positions with no route to the goal.
+	Unreachable = 1 << 30
+
+	// BrickCost is the extra cost of each brick sub-block a tank has to
+	// shoot through, in steps: roughly how far it could have driven in the
+	// time it takes to clear the way.
+	BrickCost = 4
+
+	// footprints along each axis: a tank's top-left sub-block can be
+	// anywhere but the last row and column.
+	fieldW = config.GridWidth - 1
+	fieldH = config.GridHeight - 1
+
+	// maxPaths bounds how many fields a Paths keeps. Goals that follow a
+	// moving tank come and go, so the cache is simply emptied when full.
+	maxPaths = 32
+)
+
+// FlowField holds, for every position a tank can occupy, the cost of the
+// cheapest route from there to a 2x2 goal: one per sub-block step plus
+// BrickCost for every brick sub-block on the way. Steel and water are
+// impassable. Positions are the tank's top-left sub-block.
+//
+// Every enemy heading for the same goal shares one field, and a field is
+// only recomputed when the grid or the goal has changed.
+type FlowField struct {
+	Cost [fieldH][fieldW]int
+
+	tiles [config.GridHeight][config.GridWidth]world.TileType // grid the costs were computed for
+	goal  [2]int
+	valid bool
+}
+
+// NewFlowField returns a field that is computed on its first Update.
+func NewFlowField() *FlowField {
+	return &FlowField{}
+}
+
+// Update recomputes the field for a goal whose top-left sub-block is at
+// goal, unless neither it nor the grid has changed since the last call.
+// The goal's own tiles count as passable, so routes to the eagle end
+// against it and routes to a player end on its tank.
+func (f *FlowField) Update(grid *world.Grid, goal [2]int) {
+	if f.valid && f.goal == goal && f.tiles == grid.Tiles {
+		return
+	}
+	f.valid = true
+	f.goal = goal
+	f.tiles = grid.Tiles
+
+	for y := range f.Cost {
+		for x := range f.Cost[y] {
+			f.Cost[y][x] = Unreachable
+		}
+	}
+
+	// Search outward from every position overlapping the goal.
+	var q fieldQueue
+	for y := goal[1] - 1; y <= goal[1]+1; y++ {
+		for x := goal[0] - 1; x <= goal[0]+1; x++ {
+			if f.fits(x, y) {
+				f.Cost[y][x] = 0
+				heap.Push(&q, fieldNode{x, y, 0})
+			}
+		}
+	}
+	for q.Len() > 0 {
+		n := heap.Pop(&q).(fieldNode)
+		if n.cost > f.Cost[n.y][n.x] {
+			continue
+		}
+		for _, d := range entity.AllDirections {
+			// A tank at (x, y) drives in direction -d to reach n.
+			x, y := n.x+int(d.DX()), n.y+int(d.DY())
+			if !f.fits(x, y) {
+				continue
+			}
+			c := n.cost + f.stepCost(x, y, d.Opposite())
+			if c < f.Cost[y][x] {
+				f.Cost[y][x] = c
+				heap.Push(&q, fieldNode{x, y, c})
+			}
+		}
+	}
+}
+
+// CostAt returns the cost of the route from footprint position (x, y),
+// or Unreachable if there is none.
+func (f *FlowField) CostAt(x, y int) int {
+	if !f.valid || x < 0 || y < 0 || x >= fieldW || y >= fieldH {
+		return Unreachable
+	}
+	return f.Cost[y][x]
+}
+
+// Next returns the direction a tank at footprint position (x, y) should
+// drive in to follow the cheapest route to the goal. It returns false if
+// the tank is on the goal or there is no route.
+func (f *FlowField) Next(x, y int) (entity.Direction, bool) {
+	if !f.valid || !f.fits(x, y) || f.Cost[y][x] == 0 || f.Cost[y][x] == Unreachable {
+		return 0, false
+	}
+	best, bestCost := entity.Direction(0), Unreachable
+	for _, d := range entity.AllDirections {
+		nx, ny := x+int(d.DX()), y+int(d.DY())
+		if !f.fits(nx, ny) || f.Cost[ny][nx] == Unreachable {
+			continue
+		}
+		if c := f.stepCost(x, y, d) + f.Cost[ny][nx]; c < bestCost {
+			best, bestCost = d, c
+		}
+	}
+	return best, bestCost < Unreachable
+}
+
+// Paths hands out flow fields by goal, so that every enemy heading for the
+// same place shares one field. Fields only depend on the grid and their
+// goal, so the cache never changes where an enemy goes.
+type Paths struct {
+	fields map[[2]int]*FlowField
+}
+
+// NewPaths returns an empty cache.
+func NewPaths() *Paths {
+	return &Paths{fields: make(map[[2]int]*FlowField)}
+}
+
+// To returns the field leading to the 2x2 goal whose top-left sub-block is
+// at goal, up to date with grid.
+func (p *Paths) To(grid *world.Grid, goal [2]int) *FlowField {
+	f, ok := p.fields[goal]
+	if !ok {
+		if len(p.fields) >= maxPaths {
+			clear(p.fields)
+		}
+		f = NewFlowField()
+		p.fields[goal] = f
+	}
+	f.Update(grid, goal)
+	return f
+}
+
+// Footprint returns the footprint position, in sub-blocks, nearest to the
+// pixel position (x, y).
+func Footprint(x, y float64) [2]int {
+	return [2]int{
+		int(math.Round(x / config.SubBlock)),
+		int(math.Round(y / config.SubBlock)),
+	}
+}
+
+// tile returns the tile at (x, y) as the field sees it: the goal's own
+// tiles are empty.
+func (f *FlowField) tile(x, y int) world.TileType {
+	if x < 0 || y < 0 || x >= config.GridWidth || y >= config.GridHeight {
+		return world.TileSteel
+	}
+	if x-f.goal[0] >= 0 && x-f.goal[0] < 2 && y-f.goal[1] >= 0 && y-f.goal[1] < 2 {
+		return world.TileEmpty
+	}
+	return f.tiles[y][x]
+}
+
+// fits reports whether a tank can occupy footprint position (x, y), given
+// enough shooting.
+func (f *FlowField) fits(x, y int) bool {
+	if x < 0 || y < 0 || x >= fieldW || y >= fieldH {
+		return false
+	}
+	for dy := 0; dy < 2; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			if t := f.tile(x+dx, y+dy); !t.IsPassable() && t != world.TileBrick {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stepCost returns the cost of driving one sub-block in direction d from
+// footprint position (x, y): the two sub-blocks the tank's leading edge
+// moves into are the only new ones it covers.
+func (f *FlowField) stepCost(x, y int, d entity.Direction) int {
+	cost := 1
+	for _, c := range leadingEdge(x, y, d) {
+		if f.tile(c[0], c[1]) == world.TileBrick {
+			cost += BrickCost
+		}
+	}
+	return cost
+}
+
+// leadingEdge returns the two sub-blocks a tank at footprint position
+// (x, y) moves into when it drives one step in direction d.
+func leadingEdge(x, y int, d entity.Direction) [2][2]int {
+	switch d {
+	case entity.DirUp:
+		return [2][2]int{{x, y - 1}, {x + 1, y - 1}}
+	case entity.DirDown:
+		return [2][2]int{{x, y + 2}, {x + 1, y + 2}}
+	case entity.DirLeft:
+		return [2][2]int{{x - 1, y}, {x - 1, y + 1}}
+	default:
+		return [2][2]int{{x + 2, y}, {x + 2, y + 1}}
+	}
+}
+
+type fieldNode struct {
+	x, y, cost int
+}
+
+// fieldQueue is a min-heap of nodes by cost, ties broken by position so
+// that the search order never depends on anything but the grid.
+type fieldQueue []fieldNode
+
+func (q fieldQueue) Len() int { return len(q) }
+func (q fieldQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	if q[i].y != q[j].y {
+		return q[i].y < q[j].y
+	}
+	return q[i].x < q[j].x
+}
+func (q fieldQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *fieldQueue) Push(x any)   { *q = append(*q, x.(fieldNode)) }
+func (q *fieldQueue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
@@ -0,0 +1,127 @@
+package system
+
+import (
+	"math"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// Sight is the kind of thing a shot would hit first.
+type Sight int
+
+const (
+	SightNothing Sight = iota // the shot leaves the play area
+	SightPlayer
+	SightEagle
+	SightBrick
+	SightSteel
+	SightEnemy
+)
+
+// Shot describes the path of a bullet traced ahead of time.
+type Shot struct {
+	Sight Sight
+	Dist  float64      // pixels the bullet flies before the hit
+	Tile  [2]int       // sub-block struck, for brick, steel and the eagle
+	Tank  *entity.Tank // tank struck, for players and enemies
+}
+
+// CastShot traces the bullet t would fire in direction d through the grid
+// and past the given tanks, and reports the first thing in its path. The
+// shooter itself is skipped. Enemy bullets fly through other enemies, but
+// an enemy in the way still shows up, so the AI can hold fire instead of
+// shooting across its own side.
+func CastShot(t *entity.Tank, d entity.Direction, grid *world.Grid,
+	players []*entity.PlayerTank, enemies []*entity.EnemyTank) Shot {
+
+	bx, by := t.Muzzle(d)
+	half := float64(config.BulletSize) / 2
+	px, py := bx+half, by+half
+	shot := castTiles(px, py, d, grid)
+
+	// Tanks the bullet's box crosses before the tile is reached.
+	consider := func(o *entity.Tank, s Sight) {
+		if o == t || !o.Alive {
+			return
+		}
+		box := TankBBox(o)
+		var dist float64
+		switch d {
+		case entity.DirUp:
+			if box.X >= px+half || box.X+box.W <= px-half || box.Y >= py+half {
+				return
+			}
+			dist = py - half - (box.Y + box.H)
+		case entity.DirDown:
+			if box.X >= px+half || box.X+box.W <= px-half || box.Y+box.H <= py-half {
+				return
+			}
+			dist = box.Y - (py + half)
+		case entity.DirLeft:
+			if box.Y >= py+half || box.Y+box.H <= py-half || box.X >= px+half {
+				return
+			}
+			dist = px - half - (box.X + box.W)
+		default:
+			if box.Y >= py+half || box.Y+box.H <= py-half || box.X+box.W <= px-half {
+				return
+			}
+			dist = box.X - (px + half)
+		}
+		if dist = math.Max(0, dist); dist < shot.Dist {
+			shot = Shot{Sight: s, Dist: dist, Tank: o}
+		}
+	}
+	for _, p := range players {
+		consider(&p.Tank, SightPlayer)
+	}
+	for _, e := range enemies {
+		consider(&e.Tank, SightEnemy)
+	}
+	return shot
+}
+
+// castTiles steps a bullet centred on (px, py) through the grid, one
+// sub-block at a time, until it reaches a tile that stops it or leaves the
+// play area. Like BulletGridCollision it only looks at the sub-block under
+// the bullet's centre.
+func castTiles(px, py float64, d entity.Direction, grid *world.Grid) Shot {
+	sb := float64(config.SubBlock)
+	x, y := int(math.Floor(px/sb)), int(math.Floor(py/sb))
+	dx, dy := int(d.DX()), int(d.DY())
+	for ; x >= 0 && y >= 0 && x < config.GridWidth && y < config.GridHeight; x, y = x+dx, y+dy {
+		var s Sight
+		switch grid.Get(x, y) {
+		case world.TileBrick:
+			s = SightBrick
+		case world.TileSteel:
+			s = SightSteel
+		case world.TileEagle:
+			s = SightEagle
+		default:
+			continue
+		}
+		return Shot{Sight: s, Dist: edgeDist(px, py, x, y, d), Tile: [2]int{x, y}}
+	}
+	return Shot{Sight: SightNothing, Dist: edgeDist(px, py, x, y, d)}
+}
+
+// edgeDist returns how far a bullet centred on (px, py) flies in direction
+// d before its centre enters sub-block (x, y).
+func edgeDist(px, py float64, x, y int, d entity.Direction) float64 {
+	sb := float64(config.SubBlock)
+	var dist float64
+	switch d {
+	case entity.DirUp:
+		dist = py - float64(y+1)*sb
+	case entity.DirDown:
+		dist = float64(y)*sb - py
+	case entity.DirLeft:
+		dist = px - float64(x+1)*sb
+	default:
+		dist = float64(x)*sb - px
+	}
+	return math.Max(0, dist)
+}
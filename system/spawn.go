@@ -1,46 +1,74 @@
 package system
 
 import (
-	"math/rand"
+	"math/rand/v2"
 
 	"github.com/AchrafSoltani/TankStrike/config"
 	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/world"
 )
 
-// Spawn points (sub-block coordinates, top row)
-var SpawnPoints = [][2]int{
-	{0, 0},   // top-left
-	{12, 0},  // top-centre
-	{24, 0},  // top-right
-}
-
 // Spawner manages enemy spawning.
 type Spawner struct {
 	Queue         []entity.EnemyType // remaining enemies to spawn
+	Points        [][2]int           // spawn points (sub-block coordinates)
 	Timer         float64
 	NextSpawnIdx  int // cycles through spawn points
 	TotalSpawned  int
 	TotalForLevel int
+	SinceDrop     int           // enemies spawned since the last power-up carrier
+	Preset        config.Preset // balance of the enemies it spawns
 }
 
-// NewSpawner creates a new spawner for a level.
-func NewSpawner(level int) *Spawner {
+// NewSpawner creates a new spawner for stage number index using the spawn
+// points and roster of def, balanced by preset. Random rosters and shuffles
+// are drawn from rng.
+func NewSpawner(index int, def *world.Level, preset config.Preset, rng *rand.Rand) *Spawner {
 	s := &Spawner{
-		Timer:         2.0, // initial delay before first spawn
-		TotalForLevel: config.EnemiesPerLevel,
+		Points: def.EnemySpawns,
+		Timer:  2.0, // initial delay before first spawn
+		Preset: preset,
+	}
+	if len(s.Points) == 0 {
+		s.Points = world.DefaultEnemySpawns
 	}
-	s.buildQueue(level)
+	if def.Roster.Total() > 0 {
+		s.buildRosterQueue(def.Roster, rng)
+	} else {
+		s.buildQueue(index+preset.MixShift, rng)
+	}
+	s.TotalForLevel = len(s.Queue)
 	return s
 }
 
-func (s *Spawner) buildQueue(level int) {
+// buildRosterQueue queues a fixed roster in shuffled order.
+func (s *Spawner) buildRosterQueue(roster world.EnemyCounts, rng *rand.Rand) {
+	s.Queue = make([]entity.EnemyType, 0, roster.Total())
+	for i := 0; i < roster.Basic; i++ {
+		s.Queue = append(s.Queue, entity.EnemyBasic)
+	}
+	for i := 0; i < roster.Fast; i++ {
+		s.Queue = append(s.Queue, entity.EnemyFast)
+	}
+	for i := 0; i < roster.Power; i++ {
+		s.Queue = append(s.Queue, entity.EnemyPower)
+	}
+	for i := 0; i < roster.Armour; i++ {
+		s.Queue = append(s.Queue, entity.EnemyArmour)
+	}
+	rng.Shuffle(len(s.Queue), func(i, j int) {
+		s.Queue[i], s.Queue[j] = s.Queue[j], s.Queue[i]
+	})
+}
+
+func (s *Spawner) buildQueue(level int, rng *rand.Rand) {
 	total := config.EnemiesPerLevel
 	s.Queue = make([]entity.EnemyType, 0, total)
 
 	// Mix of enemy types depends on level
 	for i := 0; i < total; i++ {
 		var typ entity.EnemyType
-		roll := rand.Float64()
+		roll := rng.Float64()
 		switch {
 		case level < 3:
 			if roll < 0.6 {
@@ -75,13 +103,14 @@ func (s *Spawner) buildQueue(level int) {
 	}
 }
 
-// Update checks if it's time to spawn a new enemy.
+// Update checks if it's time to spawn a new enemy, with the pacing, mix
+// and power-up frequency the director asks for. Random draws come from rng.
 // Returns a new enemy tank if one should spawn, nil otherwise.
-func (s *Spawner) Update(dt float64, activeEnemies int) *entity.EnemyTank {
+func (s *Spawner) Update(dt float64, activeEnemies int, d *Director, rng *rand.Rand) *entity.EnemyTank {
 	if len(s.Queue) == 0 {
 		return nil
 	}
-	if activeEnemies >= config.MaxActiveEnemies {
+	if activeEnemies >= d.MaxActive(s.Preset.MaxActiveEnemies) {
 		return nil
 	}
 
@@ -90,23 +119,30 @@ func (s *Spawner) Update(dt float64, activeEnemies int) *entity.EnemyTank {
 		return nil
 	}
 
-	s.Timer = config.SpawnInterval
+	s.Timer = d.SpawnInterval(s.Preset.SpawnInterval)
 
 	// Pick spawn point
-	sp := SpawnPoints[s.NextSpawnIdx%len(SpawnPoints)]
+	sp := s.Points[s.NextSpawnIdx%len(s.Points)]
 	s.NextSpawnIdx++
 
-	typ := s.Queue[0]
+	typ := d.Mix(s.Queue[0], rng)
 	s.Queue = s.Queue[1:]
 
-	// Every 4th enemy carries a power-up
-	hasPowerUp := s.TotalSpawned%4 == 3
+	// Every Nth enemy carries a power-up
+	s.SinceDrop++
+	hasPowerUp := s.SinceDrop >= d.PowerUpEvery(s.Preset.PowerUpEvery)
+	if hasPowerUp {
+		s.SinceDrop = 0
+	}
 	s.TotalSpawned++
 
 	x := float64(sp[0] * config.SubBlock)
 	y := float64(sp[1] * config.SubBlock)
 
-	return entity.NewEnemyTank(x, y, typ, hasPowerUp)
+	e := entity.NewEnemyTank(x, y, typ, hasPowerUp)
+	e.Speed *= s.Preset.EnemySpeed
+	e.ShootChance *= s.Preset.ShootChance
+	return e
 }
 
 // Remaining returns the number of enemies still to spawn.
@@ -0,0 +1,302 @@
+package system
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/AchrafSoltani/TankStrike/entity"
+)
+
+// Personality files (.ai) give the behaviour tree each enemy type runs. A
+// versioned header is followed by definitions of the form "name = node",
+// which may span lines. A node is a name, with its arguments in
+// parentheses if it takes any. '#' starts a comment.
+//
+//	tankstrike-ai 1
+//	breach = sequence(breaching, fire)
+//	basic = parallel(selector(breach, random-shot), patrol(0.3))
+//
+// selector, sequence and parallel take their children; clear-shot,
+// in-sights, face and route a target, player or eagle; flank a distance
+// in sub-blocks; and patrol an optional eagle bias from 0 to 1. Any other
+// name is a node without arguments or an earlier definition. Defining
+// basic, fast, power and armour sets those enemy types' trees, and each
+// of them must be defined.
+const (
+	PersonalityFileMagic   = "tankstrike-ai"
+	PersonalityFileVersion = 1
+)
+
+// leafNodes are the nodes that take no arguments, by name.
+var leafNodes = map[string]Node{
+	"breaching":   Breaching{},
+	"loaded":      Loaded{},
+	"random-shot": RandomShot{},
+	"useful":      Useful{},
+	"steady":      Steady{},
+	"sloppy":      Sloppy{},
+	"fire":        Fire{},
+	"hold":        Hold{},
+	"patrol":      Patrol{},
+	"detour":      Detour{},
+}
+
+// compositeNodes build the nodes that take children, by name.
+var compositeNodes = map[string]func([]Node) Node{
+	"selector": func(n []Node) Node { return Selector(n) },
+	"sequence": func(n []Node) Node { return Sequence(n) },
+	"parallel": func(n []Node) Node { return Parallel(n) },
+}
+
+// targetNodes build the nodes that take a target, by name.
+var targetNodes = map[string]func(Target) Node{
+	"clear-shot": func(t Target) Node { return ClearShot{t} },
+	"in-sights":  func(t Target) Node { return InSights{t} },
+	"face":       func(t Target) Node { return Face{t} },
+	"route":      func(t Target) Node { return Route{t} },
+}
+
+// numberNodes build the nodes that take a number, by name.
+var numberNodes = map[string]func(float64) (Node, error){
+	"flank": func(v float64) (Node, error) {
+		if v < 0 || v > fieldW || v != math.Trunc(v) {
+			return nil, fmt.Errorf("flank: distance must be a whole number of sub-blocks from 0 to %d", fieldW)
+		}
+		return Flank{Dist: int(v)}, nil
+	},
+	"patrol": func(v float64) (Node, error) {
+		if !(v >= 0 && v <= 1) {
+			return nil, fmt.Errorf("patrol: eagle bias must be from 0 to 1")
+		}
+		return Patrol{EagleBias: v}, nil
+	},
+}
+
+var targetNames = map[string]Target{
+	"player": TargetPlayer,
+	"eagle":  TargetEagle,
+}
+
+// personalityNames are the definitions that set an enemy type's tree.
+var personalityNames = map[string]entity.EnemyType{
+	"basic":  entity.EnemyBasic,
+	"fast":   entity.EnemyFast,
+	"power":  entity.EnemyPower,
+	"armour": entity.EnemyArmour,
+}
+
+// ParsePersonalities parses a personality file. Errors are positioned as
+// "line: msg".
+func ParsePersonalities(data []byte) (map[entity.EnemyType]Node, error) {
+	p := &treeParser{src: data, line: 1, defs: make(map[string]Node)}
+	if err := p.header(); err != nil {
+		return nil, err
+	}
+	trees := make(map[entity.EnemyType]Node)
+	for p.next(); p.tok != ""; p.next() {
+		name, line := p.tok, p.tokLine
+		if !isName(name) {
+			return nil, p.errorf("expected a definition but got %q", name)
+		}
+		if _, ok := p.defs[name]; ok {
+			return nil, fmt.Errorf("%d: %q is already defined", line, name)
+		}
+		if _, target := targetNames[name]; target || isNodeName(name) {
+			return nil, fmt.Errorf("%d: %q is the name of a node or target", line, name)
+		}
+		if p.next(); p.tok != "=" {
+			return nil, p.errorf("expected \"=\" after %q", name)
+		}
+		p.next()
+		n, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		p.defs[name] = n
+		if typ, ok := personalityNames[name]; ok {
+			trees[typ] = n
+		}
+	}
+	for _, name := range []string{"basic", "fast", "power", "armour"} {
+		if _, ok := p.defs[name]; !ok {
+			return nil, fmt.Errorf("no personality defined for %s tanks", name)
+		}
+	}
+	return trees, nil
+}
+
+// treeParser reads a personality file a token at a time. A token is a
+// name or number, or one of "(", ")", "," and "=".
+type treeParser struct {
+	src     []byte
+	pos     int
+	line    int
+	tok     string // current token, "" at the end of the file
+	tokLine int    // line the current token is on
+	defs    map[string]Node
+}
+
+func (p *treeParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%d: %s", p.tokLine, fmt.Sprintf(format, args...))
+}
+
+// header reads the versioned header line.
+func (p *treeParser) header() error {
+	p.next()
+	if p.tok != PersonalityFileMagic {
+		return p.errorf("expected %q header", PersonalityFileMagic)
+	}
+	magicLine := p.tokLine
+	p.next()
+	version, err := strconv.Atoi(p.tok)
+	if err != nil || p.tokLine != magicLine {
+		return fmt.Errorf("%d: expected %q header", magicLine, PersonalityFileMagic)
+	}
+	if version != PersonalityFileVersion {
+		return p.errorf("unsupported personality format version %d", version)
+	}
+	return nil
+}
+
+// next moves on to the next token.
+func (p *treeParser) next() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '\n':
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			p.tokLine = p.line
+			start := p.pos
+			p.pos++
+			if isWordByte(c) {
+				for p.pos < len(p.src) && isWordByte(p.src[p.pos]) {
+					p.pos++
+				}
+			}
+			p.tok = string(p.src[start:p.pos])
+			return
+		}
+	}
+	p.tok, p.tokLine = "", p.line
+}
+
+// node parses the node starting at the current token, leaving the last
+// token of it current.
+func (p *treeParser) node() (Node, error) {
+	name := p.tok
+	if !isName(name) {
+		return nil, p.errorf("expected a node but got %q", name)
+	}
+	if p.peek() != '(' {
+		if n, ok := p.defs[name]; ok {
+			return n, nil
+		}
+		if n := leafNodes[name]; n != nil {
+			return n, nil
+		}
+		if isNodeName(name) {
+			return nil, p.errorf("%s: expected an argument", name)
+		}
+		return nil, p.errorf("unknown node %q", name)
+	}
+	p.next() // "("
+
+	if build, ok := compositeNodes[name]; ok {
+		var children []Node
+		for p.next(); p.tok != ")"; p.next() {
+			n, err := p.node()
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, n)
+			if p.next(); p.tok == ")" {
+				break
+			}
+			if p.tok != "," {
+				return nil, p.errorf("%s: expected \",\" or \")\" but got %q", name, p.tok)
+			}
+		}
+		if len(children) == 0 {
+			return nil, p.errorf("%s: expected at least one child", name)
+		}
+		return build(children), nil
+	}
+
+	buildTarget, target := targetNodes[name]
+	buildNumber, number := numberNodes[name]
+	if !target && !number {
+		if _, def := p.defs[name]; def || isNodeName(name) {
+			return nil, p.errorf("%s takes no arguments", name)
+		}
+		return nil, p.errorf("unknown node %q", name)
+	}
+	p.next()
+	arg := p.tok
+	if arg == ")" {
+		return nil, p.errorf("%s: expected one argument", name)
+	}
+	if p.next(); p.tok == "," {
+		p.next()
+	}
+	if p.tok != ")" {
+		return nil, p.errorf("%s: expected one argument", name)
+	}
+	if target {
+		t, ok := targetNames[arg]
+		if !ok {
+			return nil, p.errorf("%s: unknown target %q, want player or eagle", name, arg)
+		}
+		return buildTarget(t), nil
+	}
+	v, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, p.errorf("%s: bad number %q", name, arg)
+	}
+	n, err := buildNumber(v)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return n, nil
+}
+
+// peek returns the next byte that is not space or a comment, or 0 at the
+// end of the file, without moving on.
+func (p *treeParser) peek() byte {
+	for i := p.pos; i < len(p.src); i++ {
+		switch c := p.src[i]; c {
+		case ' ', '\t', '\r', '\n':
+		case '#':
+			for i < len(p.src) && p.src[i] != '\n' {
+				i++
+			}
+		default:
+			return c
+		}
+	}
+	return 0
+}
+
+// isNodeName reports whether name is one of the built-in nodes.
+func isNodeName(name string) bool {
+	_, leaf := leafNodes[name]
+	_, composite := compositeNodes[name]
+	_, target := targetNodes[name]
+	_, number := numberNodes[name]
+	return leaf || composite || target || number
+}
+
+func isName(s string) bool {
+	return s != "" && isWordByte(s[0]) && (s[0] < '0' || s[0] > '9') && s[0] != '.' && s[0] != '-'
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_'
+}
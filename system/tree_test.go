@@ -0,0 +1,122 @@
+package system
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// personalityFile returns a personality file in which basic tanks run the
+// given definitions and every other type fires.
+func personalityFile(basic string) []byte {
+	return []byte("tankstrike-ai 1\n" + basic + "\nfast = fire\npower = fire\narmour = fire\n")
+}
+
+func TestParsePersonalities(t *testing.T) {
+	trees, err := ParsePersonalities(personalityFile(`
+# shared
+aim = sequence(loaded, in-sights(player), fire) # trailing comment
+basic = parallel(
+	selector(aim, random-shot),
+	patrol(0.25),
+	flank(4),
+	face(eagle),
+)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Parallel{
+		Selector{Sequence{Loaded{}, InSights{TargetPlayer}, Fire{}}, RandomShot{}},
+		Patrol{EagleBias: 0.25},
+		Flank{Dist: 4},
+		Face{TargetEagle},
+	}
+	if got := trees[entity.EnemyBasic]; !reflect.DeepEqual(got, want) {
+		t.Errorf("basic tree is\n%#v\nwant\n%#v", got, want)
+	}
+	if got := trees[entity.EnemyArmour]; !reflect.DeepEqual(got, Fire{}) {
+		t.Errorf("armour tree is %#v, want Fire{}", got)
+	}
+}
+
+func TestParsePersonalitiesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, `1: expected "tankstrike-ai" header`},
+		{"other magic", []byte("tankstrike-sfx 1\n"), `1: expected "tankstrike-ai" header`},
+		{"version on the next line", []byte("tankstrike-ai\n1\n"), `1: expected "tankstrike-ai" header`},
+		{"newer version", []byte("tankstrike-ai 2\n"), "1: unsupported personality format version 2"},
+		{"unknown node", personalityFile("basic = spin"), `2: unknown node "spin"`},
+		{"later definition", personalityFile("basic = later\nlater = fire"), `2: unknown node "later"`},
+		{"unknown composite", personalityFile("basic = spin(fire)"), `2: unknown node "spin"`},
+		{"missing =", personalityFile("basic fire"), `2: expected "=" after "basic"`},
+		{"not a definition", personalityFile("= fire"), `2: expected a definition but got "="`},
+		{"missing comma", personalityFile("basic = sequence(fire hold)"), `2: sequence: expected "," or ")" but got "hold"`},
+		{"missing )", []byte("tankstrike-ai 1\nbasic = sequence(fire,\n  hold"), `3: sequence: expected "," or ")" but got ""`},
+		{"extra )", personalityFile("basic = sequence(fire))"), `2: expected a definition but got ")"`},
+		{"missing child", personalityFile("basic = sequence(fire, , hold)"), `2: expected a node but got ","`},
+		{"no children", personalityFile("basic = selector()"), "2: selector: expected at least one child"},
+		{"composite without (", personalityFile("basic = selector"), "2: selector: expected an argument"},
+		{"target without (", personalityFile("basic = face"), "2: face: expected an argument"},
+		{"leaf with arguments", personalityFile("basic = fire(player)"), "2: fire takes no arguments"},
+		{"definition with arguments", personalityFile("shoot = fire\nbasic = shoot(1)"), "3: shoot takes no arguments"},
+		{"unknown target", personalityFile("basic = face(tank)"), `2: face: unknown target "tank", want player or eagle`},
+		{"two arguments", personalityFile("basic = face(player, eagle)"), "2: face: expected one argument"},
+		{"no argument", personalityFile("basic = route()"), "2: route: expected one argument"},
+		{"bad number", personalityFile("basic = flank(far)"), `2: flank: bad number "far"`},
+		{"fractional flank", personalityFile("basic = flank(1.5)"), "2: flank: distance must be a whole number"},
+		{"patrol bias above 1", personalityFile("basic = patrol(2)"), "2: patrol: eagle bias must be from 0 to 1"},
+		{"NaN patrol bias", personalityFile("basic = patrol(NaN)"), "2: patrol: eagle bias must be from 0 to 1"},
+		{"redefinition", personalityFile("basic = fire\nbasic = hold"), `3: "basic" is already defined`},
+		{"node name", personalityFile("fire = hold"), `2: "fire" is the name of a node or target`},
+		{"target name", personalityFile("eagle = hold"), `2: "eagle" is the name of a node or target`},
+		{"missing personality", []byte("tankstrike-ai 1\nbasic = fire\nfast = fire\npower = fire\n"), "no personality defined for armour tanks"},
+	}
+	for _, tc := range tests {
+		_, err := ParsePersonalities(tc.data)
+		if err == nil {
+			t.Errorf("%s: parsed", tc.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+// TestBuiltinPersonalities checks that the embedded file gives a tree to
+// every enemy type the spawner can queue, with or without a fixed roster.
+func TestBuiltinPersonalities(t *testing.T) {
+	trees, err := ParsePersonalities(builtinPersonalities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spawned := make(map[entity.EnemyType]bool)
+	rng := rand.New(rand.NewPCG(1, 2))
+	roster := &world.Level{Roster: world.EnemyCounts{Basic: 1, Fast: 1, Power: 1, Armour: 1}}
+	for _, s := range []*Spawner{
+		NewSpawner(0, roster, config.DifficultyNormal.Preset(), rng),
+		NewSpawner(0, &world.Level{}, config.DifficultyEasy.Preset(), rng),
+		NewSpawner(20, &world.Level{}, config.DifficultyNightmare.Preset(), rng),
+	} {
+		for _, typ := range s.Queue {
+			spawned[typ] = true
+		}
+	}
+	if len(spawned) != 4 {
+		t.Errorf("spawners queued %d enemy types, want 4", len(spawned))
+	}
+	for typ := range spawned {
+		if trees[typ] == nil {
+			t.Errorf("no personality for enemy type %d", typ)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package term
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/entity"
+	"github.com/AchrafSoltani/TankStrike/render"
+	"github.com/AchrafSoltani/TankStrike/sim"
+	"github.com/AchrafSoltani/TankStrike/world"
+	"github.com/AchrafSoltani/glow"
+)
+
+const (
+	// pixelSize is how many game pixels one terminal pixel covers: half a
+	// sub-block, so a tile is 2x2 pixels and a tank 4x4.
+	pixelSize = config.SubBlock / 2
+
+	// fieldPixels is the play area's side in terminal pixels. It is drawn
+	// inside a one-pixel border.
+	fieldPixels = config.PlayAreaWidth / pixelSize
+
+	hudX     = fieldPixels + 4 // first column of the HUD
+	hudWidth = 22
+
+	// ScreenWidth and ScreenHeight are the terminal size the game needs,
+	// in cells.
+	ScreenWidth  = hudX + hudWidth
+	ScreenHeight = (fieldPixels + 2) / 2
+)
+
+// glyph is a block of pixels: one row per string, one pixel per byte.
+// 'T' is tread, 'B' body and 'D' the dark barrel.
+type glyph [4]string
+
+// tankGlyphs are the tank in each direction, barrel first.
+var tankGlyphs = [4]glyph{
+	entity.DirUp:    {"TDDT", "TBBT", "TBBT", "TBBT"},
+	entity.DirDown:  {"TBBT", "TBBT", "TBBT", "TDDT"},
+	entity.DirLeft:  {"TTTT", "DBBB", "DBBB", "TTTT"},
+	entity.DirRight: {"TTTT", "BBBD", "BBBD", "TTTT"},
+}
+
+// tilePixels returns the 2x2 pixels of a tile, top row first, and whether
+// it draws at all. Water ripples over time.
+func tilePixels(t world.TileType, time float64) ([2][2]glow.Color, bool) {
+	switch t {
+	case world.TileBrick:
+		return [2][2]glow.Color{{render.ColorBrick, render.ColorBrickDark}, {render.ColorBrickDark, render.ColorBrickLight}}, true
+	case world.TileSteel:
+		return [2][2]glow.Color{{render.ColorSteelLight, render.ColorSteel}, {render.ColorSteel, render.ColorSteelDark}}, true
+	case world.TileWater:
+		a, b := render.ColorWater, render.ColorWaterWave
+		if int(time*2)%2 == 1 {
+			a, b = b, a
+		}
+		return [2][2]glow.Color{{a, b}, {b, a}}, true
+	case world.TileIce:
+		return [2][2]glow.Color{{render.ColorIceGlint, render.ColorIce}, {render.ColorIce, render.ColorIceGlint}}, true
+	case world.TileForest:
+		return [2][2]glow.Color{{render.ColorForest2, render.ColorForest1}, {render.ColorForest3, render.ColorForest2}}, true
+	case world.TileEagle:
+		return [2][2]glow.Color{{render.ColorEagleWing, render.ColorEagleBody}, {render.ColorEagleBody, render.ColorEagleWing}}, true
+	case world.TileEagleDead:
+		return [2][2]glow.Color{{render.ColorBlack, render.ColorEagleDead}, {render.ColorEagleDead, render.ColorEagleDead}}, true
+	}
+	return [2][2]glow.Color{}, false
+}
+
+var powerUpColors = map[entity.PowerUpType]glow.Color{
+	entity.PowerUpStar:   render.ColorPowerUpStar,
+	entity.PowerUpTank:   render.ColorPowerUpTank,
+	entity.PowerUpHelmet: render.ColorPowerUpHelmet,
+	entity.PowerUpShovel: render.ColorPowerUpShovel,
+	entity.PowerUpBomb:   render.ColorPowerUpBomb,
+	entity.PowerUpClock:  render.ColorPowerUpClock,
+}
+
+// blast is an explosion shown for a moment where a tank or the eagle was
+// destroyed.
+type blast struct {
+	X, Y float64
+	Left float64 // seconds
+}
+
+// blastTime is how long a blast stays on screen.
+const blastTime = 0.3
+
+// toPixel converts a play-area position in game pixels to a terminal
+// pixel, allowing for the border.
+func toPixel(v float64) int {
+	return 1 + int(math.Round(v/pixelSize))
+}
+
+// drawField draws the play area and its border.
+func drawField(s *Screen, m *sim.Sim, blasts []blast, time float64) {
+	for i := 0; i < fieldPixels+2; i++ {
+		for _, p := range [4][2]int{{i, 0}, {i, fieldPixels + 1}, {0, i}, {fieldPixels + 1, i}} {
+			s.Pixel(p[0], p[1], render.ColorHUDBG)
+		}
+	}
+	for y := 0; y < fieldPixels; y++ {
+		for x := 0; x < fieldPixels; x++ {
+			s.Pixel(1+x, 1+y, render.ColorPlayArea)
+		}
+	}
+
+	drawTiles(s, m.Grid, time, false)
+	for _, e := range m.Enemies {
+		colors := enemyColors(e.Type)
+		if e.IsFlashing() {
+			colors = render.TankColors{Body: render.ColorWhite, Tread: render.ColorYellow, Dark: render.ColorGray}
+		}
+		drawTank(s, &e.Tank, colors)
+	}
+	for _, p := range m.Players {
+		if !p.Alive {
+			continue
+		}
+		colors := render.PlayerTankColors(p.Index)
+		if p.IsInvulnerable() && int(time*8)%2 == 0 {
+			colors.Tread = render.ColorCyan
+		}
+		drawTank(s, &p.Tank, colors)
+	}
+	for _, b := range m.Bullets {
+		if !b.Active {
+			continue
+		}
+		c := render.ColorBulletPlayer
+		if !b.IsPlayer {
+			c = render.ColorBulletEnemy
+		}
+		half := float64(config.BulletSize) / 2
+		s.Pixel(toPixel(b.X+half-pixelSize/2), toPixel(b.Y+half-pixelSize/2), c)
+	}
+	for _, p := range m.PowerUps {
+		if !p.Active || !p.IsVisible() {
+			continue
+		}
+		c := powerUpColors[p.Type]
+		x, y := toPixel(p.X), toPixel(p.Y)
+		s.Pixel(x, y, render.ColorWhite)
+		s.Pixel(x+1, y, c)
+		s.Pixel(x, y+1, c)
+		s.Pixel(x+1, y+1, render.ColorWhite)
+	}
+	for _, b := range blasts {
+		inner, outer := render.ColorExplosion1, render.ColorExplosion3
+		if b.Left < blastTime/2 {
+			inner, outer = render.ColorExplosion2, render.ColorExplosion4
+		}
+		x, y := toPixel(b.X-pixelSize), toPixel(b.Y-pixelSize)
+		s.Pixel(x, y, outer)
+		s.Pixel(x+1, y, inner)
+		s.Pixel(x, y+1, inner)
+		s.Pixel(x+1, y+1, outer)
+	}
+	drawTiles(s, m.Grid, time, true)
+}
+
+// drawTiles draws either the forest, which covers tanks, or every other
+// tile.
+func drawTiles(s *Screen, g *world.Grid, time float64, forest bool) {
+	for y := 0; y < config.GridHeight; y++ {
+		for x := 0; x < config.GridWidth; x++ {
+			t := g.Get(x, y)
+			if (t == world.TileForest) != forest {
+				continue
+			}
+			px, ok := tilePixels(t, time)
+			if !ok {
+				continue
+			}
+			for j := 0; j < 2; j++ {
+				for i := 0; i < 2; i++ {
+					s.Pixel(1+x*2+i, 1+y*2+j, px[j][i])
+				}
+			}
+		}
+	}
+}
+
+// drawTank draws a tank from its direction's glyph. The treads alternate
+// light and dark, shifting as the tank moves.
+func drawTank(s *Screen, t *entity.Tank, colors render.TankColors) {
+	if !t.Alive {
+		return
+	}
+	x0, y0 := toPixel(t.X), toPixel(t.Y)
+	vertical := t.Dir == entity.DirUp || t.Dir == entity.DirDown
+	for j, row := range tankGlyphs[t.Dir] {
+		for i := 0; i < len(row); i++ {
+			var c glow.Color
+			switch row[i] {
+			case 'B':
+				c = colors.Body
+			case 'D':
+				c = colors.Dark
+			case 'T':
+				along := i
+				if vertical {
+					along = j
+				}
+				c = colors.Tread
+				if (along+t.TreadFrame/2)%2 == 0 {
+					c = colors.Dark
+				}
+			}
+			s.Pixel(x0+i, y0+j, c)
+		}
+	}
+}
+
+func enemyColors(typ entity.EnemyType) render.TankColors {
+	switch typ {
+	case entity.EnemyFast:
+		return render.EnemyFastColors
+	case entity.EnemyPower:
+		return render.EnemyPowerColors
+	case entity.EnemyArmour:
+		return render.EnemyArmourColors
+	default:
+		return render.EnemyBasicColors
+	}
+}
+
+// drawHUD writes the compact text sidebar: the stage, enemies left, each
+// player's lives, score and stars, any time limit, and what the match is
+// waiting for.
+func drawHUD(s *Screen, m *sim.Sim, status string) {
+	label, text, bg := render.ColorGray, render.ColorHUDText, render.ColorBlack
+	line := func(y int, str string, c glow.Color) {
+		s.Text(hudX, y, fmt.Sprintf("%-*s", hudWidth, str), c, bg)
+	}
+	for y := 0; y < ScreenHeight; y++ {
+		line(y, "", text)
+	}
+
+	line(0, "TANK STRIKE", render.ColorYellow)
+	line(2, fmt.Sprintf("STAGE %d", m.Level+1), text)
+	line(3, m.Def.Name, label)
+	line(5, fmt.Sprintf("ENEMY %d", m.EnemiesRemaining()), text)
+	y := 7
+	for _, p := range m.Players {
+		line(y, fmt.Sprintf("%dP  x%d  %s", p.Index+1, p.Lives, strings.Repeat("*", p.Stars)), render.PlayerTankColors(p.Index).Body)
+		line(y+1, fmt.Sprintf("    %06d", p.Score), render.ColorYellow)
+		y += 3
+	}
+	if m.Def.TimeLimit > 0 {
+		left := int(math.Ceil(math.Max(0, m.Def.TimeLimit-m.LevelTime)))
+		c := text
+		if left < 10 {
+			c = render.ColorRed
+		}
+		line(y, fmt.Sprintf("TIME %d:%02d", left/60, left%60), c)
+		y += 2
+	}
+	if status != "" {
+		line(y, status, render.ColorYellow)
+	}
+
+	line(ScreenHeight-4, "WASD/ARROWS MOVE", label)
+	line(ScreenHeight-3, "SPACE FIRE", label)
+	line(ScreenHeight-2, "P PAUSE  ENTER GO ON", label)
+	line(ScreenHeight-1, "Q QUIT", label)
+}
+
+// statusText describes what the match is doing when it is not simply in
+// play.
+func statusText(m *sim.Sim) string {
+	switch m.State {
+	case sim.StateLevelIntro:
+		return fmt.Sprintf("STAGE %d - GET READY", m.Level+1)
+	case sim.StatePaused:
+		return "PAUSED"
+	case sim.StateGameOver:
+		if m.GameOverTimer <= 0 {
+			return "GAME OVER - ENTER"
+		}
+		return "GAME OVER"
+	case sim.StateLevelComplete:
+		if m.LevelComplTimer <= 0 {
+			return "STAGE CLEAR - ENTER"
+		}
+		return "STAGE CLEAR"
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package term
+
+import (
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/system"
+)
+
+// key is a key press decoded from the terminal's input.
+type key int
+
+const (
+	keyNone key = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyFire
+	keyPause
+	keyConfirm
+	keyQuit
+	keyRedraw
+)
+
+// escapeWait is how long an escape may wait for the rest of an arrow key's
+// sequence before it counts as a press of Escape on its own.
+const escapeWait = 100 * time.Millisecond
+
+// keyDecoder turns terminal input into key presses. Arrow keys arrive as
+// escape sequences, which a slow link may split across reads, so an
+// incomplete one at the end of a read is held for the next.
+type keyDecoder struct {
+	pending []byte
+	since   time.Time // when pending was held back
+}
+
+// decode returns the keys pressed in b, read at now.
+func (d *keyDecoder) decode(b []byte, now time.Time) []key {
+	b = append(d.pending, b...)
+	keys, n := decodeKeys(b)
+	d.pending = append([]byte(nil), b[n:]...)
+	d.since = now
+	return keys
+}
+
+// expire returns a press of Escape if a held escape has waited escapeWait
+// at now with nothing completing it.
+func (d *keyDecoder) expire(now time.Time) []key {
+	if len(d.pending) == 0 || now.Sub(d.since) < escapeWait {
+		return nil
+	}
+	d.pending = nil
+	return []key{keyPause}
+}
+
+// decodeKeys turns terminal input into key presses, stopping short of an
+// escape sequence cut off at the end of b. It returns the keys and how
+// many bytes of b they used.
+func decodeKeys(b []byte) ([]key, int) {
+	var keys []key
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0x1b {
+			n, final, complete := escapeSequence(b[i:])
+			if !complete {
+				return keys, i
+			}
+			if n > 0 {
+				switch final {
+				case 'A':
+					keys = append(keys, keyUp)
+				case 'B':
+					keys = append(keys, keyDown)
+				case 'C':
+					keys = append(keys, keyRight)
+				case 'D':
+					keys = append(keys, keyLeft)
+				}
+				i += n - 1
+				continue
+			}
+		}
+		switch b[i] {
+		case 'w', 'W', 'k':
+			keys = append(keys, keyUp)
+		case 's', 'S', 'j':
+			keys = append(keys, keyDown)
+		case 'a', 'A', 'h':
+			keys = append(keys, keyLeft)
+		case 'd', 'D', 'l':
+			keys = append(keys, keyRight)
+		case ' ':
+			keys = append(keys, keyFire)
+		case 'p', 'P', 0x1b:
+			keys = append(keys, keyPause)
+		case '\r', '\n':
+			keys = append(keys, keyConfirm)
+		case 'q', 'Q', 0x03: // Ctrl-C arrives as a byte in raw mode
+			keys = append(keys, keyQuit)
+		case 0x0c: // Ctrl-L
+			keys = append(keys, keyRedraw)
+		}
+	}
+	return keys, len(b)
+}
+
+// escapeSequence measures the escape sequence at the start of b: ESC O
+// and a final byte, or a CSI, ESC [, with any parameters before its final
+// byte in 0x40-0x7E. Parameters, such as the modifiers of Ctrl+Up, are
+// skipped. It returns the sequence's length and final byte, 0 if b starts
+// with an escape that begins no sequence, and false if b ends partway
+// through one.
+func escapeSequence(b []byte) (n int, final byte, complete bool) {
+	if len(b) < 2 {
+		return 0, 0, false
+	}
+	switch b[1] {
+	case 'O':
+		if len(b) < 3 {
+			return 0, 0, false
+		}
+		return 3, b[2], true
+	case '[':
+		for j := 2; j < len(b); j++ {
+			switch c := b[j]; {
+			case c >= 0x40 && c <= 0x7e:
+				return j + 1, c, true
+			case c < 0x20 || c > 0x3f:
+				return j, 0, true // malformed: drop what came before c
+			}
+		}
+		return 0, 0, false
+	}
+	return 0, 0, true
+}
+
+const (
+	// Terminals report key presses but not releases, and a held key only
+	// starts repeating after a delay. A first press holds its button long
+	// enough to bridge that delay; once repeats arrive, each holds it just
+	// until the next, so letting go stops the tank promptly.
+	tapHold    = 600 * time.Millisecond
+	repeatHold = 120 * time.Millisecond
+)
+
+// controls turns key presses into the buttons held on each tick.
+type controls struct {
+	until  map[system.Button]time.Time // when each held button lets go
+	last   map[system.Button]time.Time // when each was last pressed
+	pulses system.Button               // pressed for a single tick
+}
+
+func newControls() *controls {
+	return &controls{until: make(map[system.Button]time.Time), last: make(map[system.Button]time.Time)}
+}
+
+var moveButtons = map[key]system.Button{
+	keyUp:    system.ButtonUp,
+	keyDown:  system.ButtonDown,
+	keyLeft:  system.ButtonLeft,
+	keyRight: system.ButtonRight,
+	keyFire:  system.ButtonFire,
+}
+
+// press records a key pressed at now.
+func (c *controls) press(k key, now time.Time) {
+	switch k {
+	case keyPause:
+		c.pulses |= system.ButtonPause
+		return
+	case keyConfirm:
+		c.pulses |= system.ButtonConfirm
+		return
+	}
+	b, ok := moveButtons[k]
+	if !ok {
+		return
+	}
+	if b != system.ButtonFire {
+		// Only one direction at a time: turning lets go of the last.
+		for d := range c.until {
+			if d != system.ButtonFire && d != b {
+				delete(c.until, d)
+			}
+		}
+	}
+	hold := tapHold
+	if now.Sub(c.last[b]) < tapHold {
+		hold = repeatHold
+	}
+	c.last[b] = now
+	c.until[b] = now.Add(hold)
+}
+
+// held returns the buttons held at now. Single-tick presses are reported
+// once.
+func (c *controls) held(now time.Time) system.Button {
+	b := c.pulses
+	c.pulses = 0
+	for btn, until := range c.until {
+		if now.Before(until) {
+			b |= btn
+		} else {
+			delete(c.until, btn)
+		}
+	}
+	return b
+}
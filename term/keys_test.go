@@ -0,0 +1,55 @@
+package term
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestKeyDecoder(t *testing.T) {
+	tests := []struct {
+		name  string
+		reads []string
+		want  []key
+	}{
+		{"letters", []string{"wasd pq"}, []key{keyUp, keyLeft, keyDown, keyRight, keyFire, keyPause, keyQuit}},
+		{"arrows", []string{"\x1b[A\x1b[B\x1bOC\x1bOD"}, []key{keyUp, keyDown, keyRight, keyLeft}},
+		{"arrow split after escape", []string{"\x1b", "[A"}, []key{keyUp}},
+		{"arrow split after bracket", []string{"\x1b[", "D"}, []key{keyLeft}},
+		{"arrow split after O", []string{"a\x1bO", "B"}, []key{keyLeft, keyDown}},
+		{"modified arrow", []string{"\x1b[1;5A"}, []key{keyUp}},
+		{"modified arrow split", []string{"\x1b[1;", "5C"}, []key{keyRight}},
+		{"other sequence", []string{"\x1b[2~w"}, []key{keyUp}},
+		{"malformed sequence", []string{"\x1b[1\rs"}, []key{keyConfirm, keyDown}},
+		{"escape then letter", []string{"\x1bp"}, []key{keyPause, keyPause}},
+	}
+	now := time.Now()
+	for _, tc := range tests {
+		var d keyDecoder
+		var got []key
+		for _, r := range tc.reads {
+			got = append(got, d.decode([]byte(r), now)...)
+			got = append(got, d.expire(now.Add(escapeWait/2))...)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestKeyDecoderLoneEscape(t *testing.T) {
+	var d keyDecoder
+	now := time.Now()
+	if got := d.decode([]byte("\x1b"), now); len(got) != 0 {
+		t.Fatalf("escape decoded as %v before the wait", got)
+	}
+	if got := d.expire(now.Add(escapeWait / 2)); len(got) != 0 {
+		t.Fatalf("escape expired early as %v", got)
+	}
+	if got := d.expire(now.Add(escapeWait)); !slices.Equal(got, []key{keyPause}) {
+		t.Fatalf("escape expired as %v, want pause", got)
+	}
+	if got := d.expire(now.Add(2 * escapeWait)); len(got) != 0 {
+		t.Fatalf("escape expired twice: %v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/AchrafSoltani/glow"
+)
+
+// halfBlock fills the top half of a cell with the foreground colour,
+// leaving the bottom half to the background, so each cell shows two
+// square-ish pixels stacked on top of each other.
+const halfBlock = '▀'
+
+// cell is one character of the terminal.
+type cell struct {
+	ch     rune
+	fg, bg glow.Color
+}
+
+// Screen is a grid of terminal cells. Pixels are drawn two to a cell with
+// half blocks; text takes a whole cell. Flush writes only the cells that
+// changed since the last flush, to keep the stream small over SSH.
+type Screen struct {
+	w, h  int // in cells
+	cells []cell
+	prev  []cell
+	buf   bytes.Buffer
+}
+
+// NewScreen creates a blank screen of w by h cells.
+func NewScreen(w, h int) *Screen {
+	s := &Screen{w: w, h: h, cells: make([]cell, w*h), prev: make([]cell, w*h)}
+	s.Clear()
+	return s
+}
+
+// Clear blanks every cell to black.
+func (s *Screen) Clear() {
+	for i := range s.cells {
+		s.cells[i] = cell{ch: ' '}
+	}
+}
+
+// Redraw makes the next Flush write every cell, for when the terminal's
+// contents can no longer be trusted, such as after a resize.
+func (s *Screen) Redraw() {
+	clear(s.prev)
+}
+
+// Pixel sets the pixel at (x, y), where each cell holds two pixels one
+// above the other. A cell showing text becomes a pixel cell again.
+func (s *Screen) Pixel(x, y int, c glow.Color) {
+	if x < 0 || y < 0 || x >= s.w || y >= s.h*2 {
+		return
+	}
+	cl := &s.cells[y/2*s.w+x]
+	if cl.ch != halfBlock {
+		*cl = cell{ch: halfBlock, fg: cl.bg, bg: cl.bg}
+	}
+	if y%2 == 0 {
+		cl.fg = c
+	} else {
+		cl.bg = c
+	}
+}
+
+// Text writes str from cell (x, y) in the given colours, clipped to the
+// screen.
+func (s *Screen) Text(x, y int, str string, fg, bg glow.Color) {
+	if y < 0 || y >= s.h {
+		return
+	}
+	for _, r := range str {
+		if x >= 0 && x < s.w {
+			s.cells[y*s.w+x] = cell{ch: r, fg: fg, bg: bg}
+		}
+		x++
+	}
+}
+
+// Flush writes the changes since the last flush to w as ANSI escapes
+// with 24-bit colour.
+func (s *Screen) Flush(w io.Writer) error {
+	s.buf.Reset()
+	var fg, bg glow.Color
+	colours := false // whether fg and bg are what the terminal has
+	cursor := -1     // cell the terminal's cursor is on, if known
+	for i, c := range s.cells {
+		if c == s.prev[i] {
+			continue
+		}
+		if i != cursor {
+			fmt.Fprintf(&s.buf, "\x1b[%d;%dH", i/s.w+1, i%s.w+1)
+		}
+		if !colours || c.fg != fg {
+			fmt.Fprintf(&s.buf, "\x1b[38;2;%d;%d;%dm", c.fg.R, c.fg.G, c.fg.B)
+		}
+		if !colours || c.bg != bg {
+			fmt.Fprintf(&s.buf, "\x1b[48;2;%d;%d;%dm", c.bg.R, c.bg.G, c.bg.B)
+		}
+		fg, bg, colours = c.fg, c.bg, true
+		s.buf.WriteRune(c.ch)
+		cursor = i + 1
+		if cursor%s.w == 0 {
+			cursor = -1 // terminals differ on where the cursor goes after the last column
+		}
+		s.prev[i] = c
+	}
+	if s.buf.Len() == 0 {
+		return nil
+	}
+	s.buf.WriteString("\x1b[0m")
+	_, err := w.Write(s.buf.Bytes())
+	return err
+}
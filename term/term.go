@@ -0,0 +1,238 @@
+// Package term plays TankStrike in a terminal. It draws the play field
+// with half-block characters in 24-bit colour and reads keys from stdin in
+// raw mode, so a match can be played or watched over SSH with no X11.
+package term
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/netplay"
+	"github.com/AchrafSoltani/TankStrike/replay"
+	"github.com/AchrafSoltani/TankStrike/sim"
+	"github.com/AchrafSoltani/TankStrike/system"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// frameRate is how many times a second the terminal is redrawn. It is
+// well below the window's 60 to keep the stream light over SSH.
+const frameRate = 30
+
+// Options describe the match to play.
+type Options struct {
+	Seed       uint64
+	Level      int // counted from 0
+	Difficulty config.Difficulty
+	Adaptive   bool
+	Levels     []*world.Level // campaign to play, or nil for the built-in one
+	Replay     *replay.Replay // recording to watch instead of playing, if set
+
+	// Watch is a spectator's session of a LAN match to watch instead of
+	// playing, if set. The match's own seed, level and difficulty override
+	// the ones above.
+	Watch *netplay.Lockstep
+}
+
+// Run plays a match on the terminal on in and out until it ends or the
+// player quits, and returns the final score.
+func Run(in *os.File, out io.Writer, opts Options) (int, error) {
+	fd := int(in.Fd())
+	w, h, err := size(fd)
+	if err != nil {
+		return 0, fmt.Errorf("stdin is not a terminal: %w", err)
+	}
+	if w < ScreenWidth || h < ScreenHeight {
+		return 0, fmt.Errorf("the terminal is %dx%d; TankStrike needs at least %dx%d", w, h, ScreenWidth, ScreenHeight)
+	}
+
+	m := sim.NewSim(opts.Seed)
+	m.Difficulty = opts.Difficulty
+	m.Adaptive = opts.Adaptive
+	if opts.Levels != nil {
+		m.Levels = opts.Levels
+	}
+	inputs := []*system.Input{system.NewInput()}
+	var playback *replay.Playback
+	ls := opts.Watch
+	switch {
+	case ls != nil:
+		m.Seed = ls.Seed
+		m.Difficulty = ls.Difficulty
+		m.Adaptive = ls.Adaptive
+		inputs = make([]*system.Input, ls.Players)
+		for i := range inputs {
+			inputs[i] = system.NewInput()
+		}
+		m.Start(ls.Level, ls.Players)
+	case opts.Replay != nil:
+		if err := opts.Replay.CheckLevels(m.Levels); err != nil {
+			return 0, err
+		}
+		inputs = make([]*system.Input, opts.Replay.Players)
+		for i := range inputs {
+			inputs[i] = system.NewInput()
+		}
+		playback = replay.NewPlayback(opts.Replay)
+		playback.Start(m, inputs)
+	default:
+		m.Start(opts.Level, 1)
+	}
+
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return 0, err
+	}
+	defer restore()
+	io.WriteString(out, "\x1b[?1049h\x1b[?25l\x1b[2J") // alternate screen, hide cursor
+	defer io.WriteString(out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+
+	// The reader is left blocked on stdin when Run returns; it goes with
+	// the process.
+	input := make(chan []byte, 16)
+	go func() {
+		for {
+			buf := make([]byte, 64)
+			n, err := in.Read(buf)
+			if n > 0 {
+				input <- buf[:n]
+			}
+			if err != nil {
+				close(input)
+				return
+			}
+		}
+	}()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(stop)
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	screen := NewScreen(ScreenWidth, ScreenHeight)
+	ctl := newControls()
+	var dec keyDecoder
+	var blasts []blast
+	ticker := time.NewTicker(time.Second / frameRate)
+	defer ticker.Stop()
+	last := time.Now()
+	accumulator := 0.0
+
+	for {
+		select {
+		case b, ok := <-input:
+			if !ok {
+				return m.Score(), nil
+			}
+			for _, k := range dec.decode(b, time.Now()) {
+				switch k {
+				case keyQuit:
+					return m.Score(), nil
+				case keyRedraw:
+					io.WriteString(out, "\x1b[2J")
+					screen.Redraw()
+				default:
+					ctl.press(k, time.Now())
+				}
+			}
+			continue
+		case <-stop:
+			return m.Score(), nil
+		case <-resize:
+			io.WriteString(out, "\x1b[2J")
+			screen.Redraw()
+			continue
+		case now := <-ticker.C:
+			for _, k := range dec.expire(now) {
+				ctl.press(k, now)
+			}
+			accumulator += min(now.Sub(last).Seconds(), config.MaxFrameTime)
+			last = now
+			for accumulator >= config.TickDuration {
+				if ls != nil {
+					held, ok := ls.Advance(0)
+					if err := ls.Err(); err != nil {
+						return m.Score(), err
+					}
+					if !ok {
+						// Catch up once the input arrives, but not by more
+						// than a frame's worth at once.
+						accumulator = min(accumulator, config.MaxFrameTime)
+						break
+					}
+					for i, in := range inputs {
+						in.Update(held[i])
+					}
+				}
+				accumulator -= config.TickDuration
+				if playback != nil {
+					held, ok := playback.Next()
+					if !ok {
+						return m.Score(), nil
+					}
+					for i, in := range inputs {
+						var b system.Button
+						if i < len(held) {
+							b = held[i]
+						}
+						in.Update(b)
+					}
+				} else if ls == nil {
+					inputs[0].Update(ctl.held(now))
+				}
+				m.Update(config.TickDuration, inputs)
+				if ls != nil && m.Tick%replay.CheckInterval == 0 {
+					ls.Check(m.Tick, m.Checksum())
+				}
+				blasts = updateBlasts(blasts, m.Events)
+				if m.State == sim.StateEnded {
+					return m.Score(), nil
+				}
+			}
+		}
+
+		status := statusText(m)
+		switch {
+		case status != "":
+		case playback != nil:
+			status = fmt.Sprintf("REPLAY %ds", int(float64(playback.Tick)*config.TickDuration))
+		case ls != nil && ls.DesyncTick != 0:
+			status = "DESYNC"
+		case ls != nil && ls.Stalled() >= time.Second/4:
+			status = "WAITING FOR PLAYERS"
+		case ls != nil:
+			status = "WATCHING"
+		}
+		drawField(screen, m, blasts, m.Time)
+		drawHUD(screen, m, status)
+		if err := screen.Flush(out); err != nil {
+			return m.Score(), err
+		}
+	}
+}
+
+// updateBlasts ages the blasts on screen by a tick and adds one for each
+// tank or eagle destroyed in it.
+func updateBlasts(blasts []blast, events []sim.Event) []blast {
+	n := 0
+	for _, b := range blasts {
+		b.Left -= config.TickDuration
+		if b.Left > 0 {
+			blasts[n] = b
+			n++
+		}
+	}
+	blasts = blasts[:n]
+	for _, ev := range events {
+		switch ev.Type {
+		case sim.EventEnemyDestroyed, sim.EventEnemyBombed, sim.EventPlayerDestroyed, sim.EventEagleDestroyed:
+			blasts = append(blasts, blast{X: ev.X, Y: ev.Y, Left: blastTime})
+		}
+	}
+	return blasts
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package term
+
+import (
+	"errors"
+	"os"
+)
+
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, errors.New("the terminal renderer needs Linux")
+}
+
+func size(fd int) (w, h int, err error) {
+	return 0, 0, errors.New("the terminal renderer needs Linux")
+}
+
+func notifyResize(c chan<- os.Signal) {}
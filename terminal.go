@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+	"github.com/AchrafSoltani/TankStrike/netplay"
+	"github.com/AchrafSoltani/TankStrike/replay"
+	"github.com/AchrafSoltani/TankStrike/term"
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// runTerm implements "tankstrike term", which plays a match, or watches a
+// replay or a LAN match, in the terminal instead of a window. It returns
+// the process exit code.
+func runTerm(args []string) int {
+	fs := flag.NewFlagSet("term", flag.ExitOnError)
+	seed := fs.Uint64("seed", 0, "gameplay random seed (0 picks one from the clock)")
+	level := fs.Int("level", 1, "level to start on, counted from 1")
+	difficultyName := fs.String("difficulty", "normal", "difficulty preset: easy, normal, hard or nightmare")
+	director := fs.Bool("director", false, "let the adaptive director pace enemies to how you are doing")
+	levelDir := fs.String("levels", "", "play the .lvl level files in this directory instead of the built-in campaign")
+	replayPath := fs.String("replay", "", "watch this .tsr replay instead of playing")
+	watchAddr := fs.String("watch", "", "watch the LAN match hosted at this host:port instead of playing; join before the host starts it")
+	fs.Parse(args)
+
+	if *replayPath != "" && *watchAddr != "" {
+		fmt.Fprintln(os.Stderr, "term: --replay and --watch cannot be used together")
+		return 2
+	}
+	difficulty, known := config.ParseDifficulty(*difficultyName)
+	if !known {
+		fmt.Fprintln(os.Stderr, "term: --difficulty must be easy, normal, hard or nightmare")
+		return 2
+	}
+	opts := term.Options{
+		Seed:       *seed,
+		Level:      *level - 1,
+		Difficulty: difficulty,
+		Adaptive:   *director,
+	}
+	if opts.Seed == 0 {
+		opts.Seed = uint64(time.Now().UnixNano())
+	}
+	levels := world.Levels
+	if *levelDir != "" {
+		var err error
+		if levels, err = world.LoadLevelDir(*levelDir); err != nil {
+			fmt.Fprintln(os.Stderr, "term:", err)
+			return 1
+		}
+		opts.Levels = levels
+	}
+	if *level < 1 || *level > len(levels) {
+		fmt.Fprintf(os.Stderr, "term: --level must be 1-%d\n", len(levels))
+		return 2
+	}
+	if *replayPath != "" {
+		r, err := replay.Load(*replayPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "term:", err)
+			return 1
+		}
+		opts.Replay = r
+	}
+	if *watchAddr != "" {
+		ls, err := watchLAN(*watchAddr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "term:", err)
+			return 1
+		}
+		defer ls.Close()
+		opts.Watch = ls
+	}
+
+	score, err := term.Run(os.Stdin, os.Stdout, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "term:", err)
+		return 1
+	}
+	fmt.Printf("score %d\n", score)
+	return 0
+}
+
+// watchLAN joins the lobby of the host at addr as a spectator and waits
+// for the match to start.
+func watchLAN(addr string) (*netplay.Lockstep, error) {
+	c, err := netplay.NewClient(netplay.DefaultPort, config.Version)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	if err := c.Watch(addr); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "waiting for %s to start the match\n", addr)
+	for {
+		if ls := c.Poll(); ls != nil {
+			return ls, nil
+		}
+		if c.Joined == nil {
+			return nil, fmt.Errorf("%s: %s", addr, strings.ToLower(c.Rejected))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
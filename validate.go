@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AchrafSoltani/TankStrike/world"
+)
+
+// runValidate implements "tankstrike validate <files>". It prints every
+// problem as file:line:col: message and returns the process exit code.
+func runValidate(paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: tankstrike validate <level.lvl>...")
+		return 2
+	}
+
+	failed := false
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Println(err)
+			failed = true
+			continue
+		}
+		errs := world.CheckLevel(data)
+		for _, e := range errs {
+			if e.Line > 0 {
+				fmt.Printf("%s:%s\n", path, e)
+			} else {
+				fmt.Printf("%s: %s\n", path, e)
+			}
+		}
+		if len(errs) > 0 {
+			failed = true
+		} else {
+			fmt.Printf("%s: ok\n", path)
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -1,6 +1,53 @@
 package world
 
-import "github.com/AchrafSoltani/TankStrike/config"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+)
+
+// LevelError is a problem found in a level, positioned in its source text.
+type LevelError struct {
+	Line int // 1-based line number, or 0 if not tied to a line
+	Col  int // 1-based column, or 0 if not tied to a column
+	Msg  string
+}
+
+func (e *LevelError) Error() string {
+	switch {
+	case e.Line > 0 && e.Col > 0:
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("%d: %s", e.Line, e.Msg)
+	default:
+		return e.Msg
+	}
+}
+
+// LevelErrors collects every problem found in a level.
+type LevelErrors []*LevelError
+
+func (es LevelErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// errorf appends a positioned error.
+func (es *LevelErrors) errorf(line, col int, format string, args ...any) {
+	*es = append(*es, &LevelError{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)})
+}
+
+// orNil returns nil for an empty list so callers can compare against nil.
+func (es LevelErrors) orNil() error {
+	if len(es) == 0 {
+		return nil
+	}
+	return es
+}
 
 // LoadLevel parses a level string and populates the grid.
 // Level data uses characters:
@@ -8,34 +55,96 @@ import "github.com/AchrafSoltani/TankStrike/config"
 //	'.' = empty, 'B' = brick, 'S' = steel, 'W' = water,
 //	'I' = ice, 'F' = forest, 'E' = eagle
 //
-// The string should have 26 lines of 26 characters each.
-func LoadLevel(g *Grid, data string) {
+// The string should have 26 lines of 26 characters each. The grid is
+// filled as far as possible either way; any malformed rows, unknown
+// characters or a missing eagle are reported as LevelErrors with
+// positions relative to the start of data.
+func LoadLevel(g *Grid, data string) error {
+	var errs LevelErrors
 	g.Clear()
-	x, y := 0, 0
-	for _, ch := range data {
-		if ch == '\n' {
-			y++
-			x = 0
-			continue
+
+	rows := strings.Split(data, "\n")
+	if len(rows) != config.GridHeight {
+		errs.errorf(0, 0, "grid has %d rows, want %d", len(rows), config.GridHeight)
+	}
+
+	eagle := false
+	for y, row := range rows {
+		row = strings.TrimRight(row, "\r")
+		if y >= config.GridHeight {
+			break
 		}
-		if x < config.GridWidth && y < config.GridHeight {
-			switch ch {
-			case '.':
-				g.Tiles[y][x] = TileEmpty
-			case 'B':
-				g.Tiles[y][x] = TileBrick
-			case 'S':
-				g.Tiles[y][x] = TileSteel
-			case 'W':
-				g.Tiles[y][x] = TileWater
-			case 'I':
-				g.Tiles[y][x] = TileIce
-			case 'F':
-				g.Tiles[y][x] = TileForest
-			case 'E':
-				g.Tiles[y][x] = TileEagle
+		if n := len(row); n != config.GridWidth {
+			errs.errorf(y+1, 0, "row has %d columns, want %d", n, config.GridWidth)
+		}
+		for x := 0; x < len(row) && x < config.GridWidth; x++ {
+			t, ok := tileForChar(row[x])
+			if !ok {
+				errs.errorf(y+1, x+1, "unknown tile character %q", row[x])
+				continue
+			}
+			if t == TileEagle {
+				eagle = true
 			}
+			g.Tiles[y][x] = t
+		}
+	}
+	if !eagle {
+		errs.errorf(0, 0, "level has no eagle ('E')")
+	}
+	return errs.orNil()
+}
+
+// FormatGrid returns the grid in LoadLevel's character format.
+func FormatGrid(g *Grid) string {
+	var sb strings.Builder
+	for y := 0; y < config.GridHeight; y++ {
+		if y > 0 {
+			sb.WriteByte('\n')
+		}
+		for x := 0; x < config.GridWidth; x++ {
+			sb.WriteByte(charForTile(g.Tiles[y][x]))
 		}
-		x++
+	}
+	return sb.String()
+}
+
+func charForTile(t TileType) byte {
+	switch t {
+	case TileBrick:
+		return 'B'
+	case TileSteel:
+		return 'S'
+	case TileWater:
+		return 'W'
+	case TileIce:
+		return 'I'
+	case TileForest:
+		return 'F'
+	case TileEagle, TileEagleDead:
+		return 'E'
+	default:
+		return '.'
+	}
+}
+
+func tileForChar(ch byte) (TileType, bool) {
+	switch ch {
+	case '.':
+		return TileEmpty, true
+	case 'B':
+		return TileBrick, true
+	case 'S':
+		return TileSteel, true
+	case 'W':
+		return TileWater, true
+	case 'I':
+		return TileIce, true
+	case 'F':
+		return TileForest, true
+	case 'E':
+		return TileEagle, true
+	default:
+		return TileEmpty, false
 	}
 }
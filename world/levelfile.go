@@ -0,0 +1,371 @@
+package world
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Level files (.lvl) are plain text: a versioned header of "key: value"
+// lines, a "---" separator, then the 26x26 tile grid in LoadLevel's
+// character format. Blank lines and lines starting with '#' in the header
+// are ignored.
+//
+//	tankstrike-level 1
+//	name: Classic intro
+//	author: TankStrike
+//	enemies: basic=12 fast=5 power=3 armour=0   (or "random")
+//	enemy-spawns: 0,0 12,0 24,0
+//	player-spawns: 8,24 16,24                   (1P, 2P)
+//	eagle: 12,24
+//	time-limit: 0                               (seconds, 0 = none)
+//	---
+//	..........................
+//
+// Every header line except the first is optional.
+const (
+	LevelFileMagic   = "tankstrike-level"
+	LevelFileVersion = 1
+	LevelFileExt     = ".lvl"
+)
+
+// EnemyCounts is a fixed enemy roster for a level.
+type EnemyCounts struct {
+	Basic, Fast, Power, Armour int
+}
+
+// Total returns the number of enemies in the roster.
+func (c EnemyCounts) Total() int {
+	return c.Basic + c.Fast + c.Power + c.Armour
+}
+
+// Level is a stage definition: tile layout plus metadata.
+type Level struct {
+	Name         string
+	Author       string
+	Roster       EnemyCounts // zero means a random mix based on the stage number
+	EnemySpawns  [][2]int    // sub-block coordinates of enemy spawn points
+	PlayerSpawns [][2]int    // sub-block coordinates of the player spawns, 1P first
+	Eagle        [2]int      // sub-block coordinates of the eagle's top-left tile
+	TimeLimit    float64     // seconds to clear the stage; 0 means no limit
+	Layout       string      // tile grid in LoadLevel format
+	LayoutLine   int         // file line on which the grid starts
+}
+
+// Default spawn positions used when a level file does not specify them.
+var (
+	DefaultEnemySpawns  = [][2]int{{0, 0}, {12, 0}, {24, 0}}
+	DefaultPlayerSpawns = [][2]int{{8, 24}, {16, 24}, {0, 24}, {24, 24}}
+)
+
+// PlayerSpawn returns the spawn point of player i, falling back to the
+// default slots for players the level file does not place.
+func (l *Level) PlayerSpawn(i int) [2]int {
+	switch {
+	case i < len(l.PlayerSpawns):
+		return l.PlayerSpawns[i]
+	case i < len(DefaultPlayerSpawns):
+		return DefaultPlayerSpawns[i]
+	default:
+		return l.PlayerSpawns[0]
+	}
+}
+
+// Load populates the grid with the level's tiles, returning LoadLevel's
+// errors. A level from ParseLevel has already passed those checks.
+func (l *Level) Load(g *Grid) error {
+	return LoadLevel(g, l.Layout)
+}
+
+// ParseLevel parses a level file. Malformed headers are reported as a
+// *LevelError and malformed grids as LevelErrors, positioned in data.
+func ParseLevel(data []byte) (*Level, error) {
+	l, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := LoadLevel(NewGrid(), l.Layout); err != nil {
+		return nil, l.fileErrors(err.(LevelErrors))
+	}
+	return l, nil
+}
+
+// CheckLevel parses a level file and runs every validation check on it,
+// returning all problems found rather than stopping at the first.
+func CheckLevel(data []byte) LevelErrors {
+	l, err := parseHeader(data)
+	if err != nil {
+		return LevelErrors{err.(*LevelError)}
+	}
+	return l.Validate()
+}
+
+// parseHeader parses the header and splits off the layout without
+// checking the grid itself.
+func parseHeader(data []byte) (*Level, error) {
+	l := &Level{
+		EnemySpawns:  DefaultEnemySpawns,
+		PlayerSpawns: DefaultPlayerSpawns,
+		Eagle:        [2]int{-1, -1},
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	sawMagic := false
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !sawMagic {
+			var version int
+			if _, err := fmt.Sscanf(line, LevelFileMagic+" %d", &version); err != nil {
+				return nil, &LevelError{Line: lineNo, Msg: fmt.Sprintf("expected %q header", LevelFileMagic)}
+			}
+			if version != LevelFileVersion {
+				return nil, &LevelError{Line: lineNo, Msg: fmt.Sprintf("unsupported level format version %d", version)}
+			}
+			sawMagic = true
+			continue
+		}
+		if line == "---" {
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, &LevelError{Line: lineNo, Msg: `expected "key: value"`}
+		}
+		if err := l.setField(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return nil, &LevelError{Line: lineNo, Msg: err.Error()}
+		}
+	}
+	if !sawMagic {
+		return nil, &LevelError{Msg: fmt.Sprintf("missing %q header", LevelFileMagic)}
+	}
+	l.LayoutLine = lineNo + 1
+
+	var rows []string
+	for sc.Scan() {
+		rows = append(rows, strings.TrimRight(sc.Text(), "\r"))
+	}
+	for len(rows) > 0 && strings.TrimSpace(rows[len(rows)-1]) == "" {
+		rows = rows[:len(rows)-1]
+	}
+	if len(rows) == 0 {
+		return nil, &LevelError{Line: l.LayoutLine, Msg: "missing tile grid"}
+	}
+	l.Layout = strings.Join(rows, "\n")
+
+	if l.Eagle[0] < 0 {
+		l.Eagle = findTile(l.Layout, 'E')
+	}
+	return l, nil
+}
+
+// fileErrors converts errors positioned in the layout into errors
+// positioned in the level file.
+func (l *Level) fileErrors(errs LevelErrors) LevelErrors {
+	out := make(LevelErrors, len(errs))
+	for i, e := range errs {
+		fe := *e
+		if fe.Line > 0 {
+			fe.Line += l.LayoutLine - 1
+		}
+		out[i] = &fe
+	}
+	return out
+}
+
+func (l *Level) setField(key, value string) error {
+	var err error
+	switch key {
+	case "name":
+		l.Name = value
+	case "author":
+		l.Author = value
+	case "enemies":
+		l.Roster, err = parseRoster(value)
+	case "enemy-spawns":
+		l.EnemySpawns = nil
+		for _, field := range strings.Fields(value) {
+			p, perr := parsePoint(field)
+			if perr != nil {
+				return fmt.Errorf("enemy-spawns: %w", perr)
+			}
+			l.EnemySpawns = append(l.EnemySpawns, p)
+		}
+		if len(l.EnemySpawns) == 0 {
+			return fmt.Errorf("enemy-spawns: at least one spawn point required")
+		}
+	case "player-spawns", "player-spawn":
+		l.PlayerSpawns = nil
+		for _, field := range strings.Fields(value) {
+			p, perr := parsePoint(field)
+			if perr != nil {
+				return fmt.Errorf("%s: %w", key, perr)
+			}
+			l.PlayerSpawns = append(l.PlayerSpawns, p)
+		}
+		if len(l.PlayerSpawns) == 0 {
+			return fmt.Errorf("%s: at least one spawn point required", key)
+		}
+	case "eagle":
+		l.Eagle, err = parsePoint(value)
+	case "time-limit":
+		l.TimeLimit, err = strconv.ParseFloat(value, 64)
+		if err == nil && (!(l.TimeLimit >= 0) || math.IsInf(l.TimeLimit, 1)) {
+			err = fmt.Errorf("must be a finite number of seconds, not negative")
+		}
+	default:
+		return fmt.Errorf("unknown header field %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+func parsePoint(s string) ([2]int, error) {
+	xs, ys, ok := strings.Cut(s, ",")
+	if !ok {
+		return [2]int{}, fmt.Errorf("expected x,y but got %q", s)
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(xs))
+	if err != nil {
+		return [2]int{}, fmt.Errorf("bad x in %q", s)
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(ys))
+	if err != nil {
+		return [2]int{}, fmt.Errorf("bad y in %q", s)
+	}
+	return [2]int{x, y}, nil
+}
+
+func parseRoster(s string) (EnemyCounts, error) {
+	var c EnemyCounts
+	if s == "random" {
+		return c, nil
+	}
+	for _, field := range strings.Fields(s) {
+		name, countStr, ok := strings.Cut(field, "=")
+		if !ok {
+			return c, fmt.Errorf("expected type=count but got %q", field)
+		}
+		n, err := strconv.Atoi(countStr)
+		if err != nil || n < 0 {
+			return c, fmt.Errorf("bad count in %q", field)
+		}
+		switch name {
+		case "basic":
+			c.Basic = n
+		case "fast":
+			c.Fast = n
+		case "power":
+			c.Power = n
+		case "armour":
+			c.Armour = n
+		default:
+			return c, fmt.Errorf("unknown enemy type %q", name)
+		}
+	}
+	return c, nil
+}
+
+// findTile returns the position of the first occurrence of ch in a layout,
+// or (-1, -1) if there is none.
+func findTile(layout string, ch byte) [2]int {
+	for y, row := range strings.Split(layout, "\n") {
+		if x := strings.IndexByte(row, ch); x >= 0 {
+			return [2]int{x, y}
+		}
+	}
+	return [2]int{-1, -1}
+}
+
+// Encode returns the level in .lvl format.
+func (l *Level) Encode() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %d\n", LevelFileMagic, LevelFileVersion)
+	if l.Name != "" {
+		fmt.Fprintf(&b, "name: %s\n", l.Name)
+	}
+	if l.Author != "" {
+		fmt.Fprintf(&b, "author: %s\n", l.Author)
+	}
+	if r := l.Roster; r.Total() > 0 {
+		fmt.Fprintf(&b, "enemies: basic=%d fast=%d power=%d armour=%d\n", r.Basic, r.Fast, r.Power, r.Armour)
+	} else {
+		b.WriteString("enemies: random\n")
+	}
+	b.WriteString("enemy-spawns:")
+	for _, sp := range l.EnemySpawns {
+		fmt.Fprintf(&b, " %d,%d", sp[0], sp[1])
+	}
+	b.WriteByte('\n')
+	b.WriteString("player-spawns:")
+	for _, sp := range l.PlayerSpawns {
+		fmt.Fprintf(&b, " %d,%d", sp[0], sp[1])
+	}
+	b.WriteByte('\n')
+	fmt.Fprintf(&b, "eagle: %d,%d\n", l.Eagle[0], l.Eagle[1])
+	fmt.Fprintf(&b, "time-limit: %s\n", strconv.FormatFloat(l.TimeLimit, 'f', -1, 64))
+	b.WriteString("---\n")
+	b.WriteString(l.Layout)
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// Save writes the level to path in .lvl format.
+func (l *Level) Save(path string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, l.Encode(), 0644)
+}
+
+// LoadLevelFile reads and parses a single level file.
+func LoadLevelFile(path string) (*Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := ParseLevel(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+// LoadLevelDir reads every level file in dir, ordered by file name. Each
+// must also pass Validate, since the levels are about to be played.
+func LoadLevelDir(dir string) ([]*Level, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+LevelFileExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s: no %s files", dir, LevelFileExt)
+	}
+	sort.Strings(paths)
+	levels := make([]*Level, 0, len(paths))
+	for _, p := range paths {
+		l, err := LoadLevelFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if errs := l.Validate(); len(errs) > 0 {
+			return nil, fmt.Errorf("%s: %w", p, errs)
+		}
+		levels = append(levels, l)
+	}
+	return levels, nil
+}
@@ -0,0 +1,108 @@
+package world
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRows returns an open 26x26 grid with the eagle in its usual place.
+func testRows() []string {
+	rows := make([]string, 26)
+	for y := range rows {
+		rows[y] = strings.Repeat(".", 26)
+	}
+	rows[24] = rows[24][:12] + "EE" + rows[24][14:]
+	rows[25] = rows[25][:12] + "EE" + rows[25][14:]
+	return rows
+}
+
+// levelFile joins a header and grid rows into a level file.
+func levelFile(header string, rows []string) []byte {
+	return []byte(header + "---\n" + strings.Join(rows, "\n") + "\n")
+}
+
+// set returns rows with s written into row y from column x.
+func set(rows []string, x, y int, s string) []string {
+	rows[y] = rows[y][:x] + s + rows[y][x+len(s):]
+	return rows
+}
+
+const testHeader = "tankstrike-level 1\n"
+
+func TestParseLevel(t *testing.T) {
+	data := levelFile(testHeader+
+		"# a comment\n"+
+		"name: Test\n"+
+		"author: Someone\n"+
+		"enemies: basic=1 fast=2 power=3 armour=4\n"+
+		"enemy-spawns: 0,0 24,0\n"+
+		"player-spawns: 8,24 16,24\n"+
+		"time-limit: 90.5\n", testRows())
+	l, err := ParseLevel(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Level{
+		Name:         "Test",
+		Author:       "Someone",
+		Roster:       EnemyCounts{Basic: 1, Fast: 2, Power: 3, Armour: 4},
+		EnemySpawns:  [][2]int{{0, 0}, {24, 0}},
+		PlayerSpawns: [][2]int{{8, 24}, {16, 24}},
+		Eagle:        [2]int{12, 24},
+		TimeLimit:    90.5,
+		Layout:       strings.Join(testRows(), "\n"),
+		LayoutLine:   10,
+	}
+	if !reflect.DeepEqual(l, want) {
+		t.Errorf("parsed\n%+v\nwant\n%+v", l, want)
+	}
+
+	again, err := ParseLevel(l.Encode())
+	if err != nil {
+		t.Fatalf("encoded level does not parse: %v", err)
+	}
+	again.LayoutLine = l.LayoutLine
+	if !reflect.DeepEqual(again, l) {
+		t.Errorf("encoded level parses as\n%+v\nwant\n%+v", again, l)
+	}
+}
+
+func TestParseLevelErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, `missing "tankstrike-level" header`},
+		{"no header", levelFile("name: x\n", testRows()), `1: expected "tankstrike-level" header`},
+		{"other magic", levelFile("tankstrike-sfx 1\n", testRows()), `1: expected "tankstrike-level" header`},
+		{"newer version", levelFile("tankstrike-level 2\n", testRows()), "1: unsupported level format version 2"},
+		{"not key: value", levelFile(testHeader+"name\n", testRows()), `2: expected "key: value"`},
+		{"unknown key", levelFile(testHeader+"colour: red\n", testRows()), `2: unknown header field "colour"`},
+		{"unknown enemy", levelFile(testHeader+"enemies: boss=1\n", testRows()), `unknown enemy type "boss"`},
+		{"negative enemies", levelFile(testHeader+"enemies: basic=-1\n", testRows()), `bad count in "basic=-1"`},
+		{"bad spawn", levelFile(testHeader+"enemy-spawns: 0;0\n", testRows()), `enemy-spawns: expected x,y`},
+		{"no spawns", levelFile(testHeader+"player-spawns:\n", testRows()), "player-spawns: at least one spawn point required"},
+		{"bad eagle", levelFile(testHeader+"eagle: 12,x\n", testRows()), `eagle: bad y`},
+		{"negative time limit", levelFile(testHeader+"time-limit: -1\n", testRows()), "time-limit: must be"},
+		{"NaN time limit", levelFile(testHeader+"time-limit: NaN\n", testRows()), "time-limit: must be"},
+		{"infinite time limit", levelFile(testHeader+"time-limit: +Inf\n", testRows()), "time-limit: must be"},
+		{"no grid", []byte(testHeader + "---\n"), "3: missing tile grid"},
+		{"short grid", levelFile(testHeader, testRows()[:25]), "grid has 25 rows, want 26"},
+		{"long row", levelFile(testHeader, append([]string{strings.Repeat(".", 27)}, testRows()[1:]...)), "3: row has 27 columns, want 26"},
+		{"short row", levelFile(testHeader, append([]string{"....."}, testRows()[1:]...)), "3: row has 5 columns, want 26"},
+		{"unknown tile", levelFile(testHeader, set(testRows(), 4, 1, "X")), `4:5: unknown tile character 'X'`},
+		{"no eagle", levelFile(testHeader, set(set(testRows(), 12, 24, ".."), 12, 25, "..")), "level has no eagle"},
+	}
+	for _, tc := range tests {
+		_, err := ParseLevel(tc.data)
+		if err == nil {
+			t.Errorf("%s: parsed", tc.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package world
+
+import "github.com/AchrafSoltani/TankStrike/config"
+
+// CanReach reports whether a tank whose 2x2 footprint starts with its
+// top-left sub-block at from can get to overlap a tile of type target.
+// Brick counts as traversable since tanks can shoot their way through it.
+func (g *Grid) CanReach(from [2]int, target TileType) bool {
+	fits := func(x, y int) bool {
+		if x < 0 || y < 0 || x > config.GridWidth-2 || y > config.GridHeight-2 {
+			return false
+		}
+		for dy := 0; dy < 2; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				t := g.Get(x+dx, y+dy)
+				if !t.IsPassable() && t != TileBrick && t != target {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	touches := func(x, y int) bool {
+		for dy := 0; dy < 2; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				if g.Get(x+dx, y+dy) == target {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if !fits(from[0], from[1]) {
+		return false
+	}
+	var seen [config.GridHeight][config.GridWidth]bool
+	queue := [][2]int{from}
+	seen[from[1]][from[0]] = true
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if touches(p[0], p[1]) {
+			return true
+		}
+		for _, d := range [4][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+			nx, ny := p[0]+d[0], p[1]+d[1]
+			if fits(nx, ny) && !seen[ny][nx] {
+				seen[ny][nx] = true
+				queue = append(queue, [2]int{nx, ny})
+			}
+		}
+	}
+	return false
+}
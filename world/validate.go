@@ -0,0 +1,90 @@
+package world
+
+import (
+	"fmt"
+
+	"github.com/AchrafSoltani/TankStrike/config"
+)
+
+// Validate runs the design checks that ParseLevel does not: the eagle must
+// be a single 2x2 block on the 2x2 block grid matching the header, every
+// spawn point (including the default slot of any co-op player the header
+// does not place) must fit a tank on passable tiles, and every enemy spawn must
+// have a route to the eagle. Errors are positioned in the level file.
+func (l *Level) Validate() LevelErrors {
+	var errs LevelErrors
+	g := NewGrid()
+	if err := LoadLevel(g, l.Layout); err != nil {
+		errs = append(errs, l.fileErrors(err.(LevelErrors))...)
+	}
+
+	l.checkEagle(g, &errs)
+
+	for _, sp := range l.EnemySpawns {
+		l.checkSpawn(g, sp, "enemy spawn", &errs)
+	}
+	for i := 0; i < config.MaxPlayers; i++ {
+		l.checkSpawn(g, l.PlayerSpawn(i), fmt.Sprintf("player %d spawn", i+1), &errs)
+	}
+
+	for _, sp := range l.EnemySpawns {
+		if inFootprintBounds(sp) && g.IsPassable(sp[0], sp[1]) && !g.CanReach(sp, TileEagle) {
+			l.cellErrorf(&errs, sp, "no route from enemy spawn %d,%d to the eagle", sp[0], sp[1])
+		}
+	}
+	return errs
+}
+
+func (l *Level) checkEagle(g *Grid, errs *LevelErrors) {
+	var cells [][2]int
+	for y := 0; y < config.GridHeight; y++ {
+		for x := 0; x < config.GridWidth; x++ {
+			if g.Get(x, y) == TileEagle {
+				cells = append(cells, [2]int{x, y})
+			}
+		}
+	}
+	if len(cells) == 0 {
+		return // already reported by LoadLevel
+	}
+
+	top := cells[0]
+	complete := len(cells) == 4
+	for dy := 0; dy < 2 && complete; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			if g.Get(top[0]+dx, top[1]+dy) != TileEagle {
+				complete = false
+			}
+		}
+	}
+	if !complete {
+		l.cellErrorf(errs, top, "eagle must be exactly one 2x2 block of 'E' tiles, found %d tiles", len(cells))
+		return
+	}
+	if top[0]%2 != 0 || top[1]%2 != 0 {
+		l.cellErrorf(errs, top, "eagle at %d,%d is not aligned to the 2x2 block grid", top[0], top[1])
+	}
+	if l.Eagle != top {
+		l.cellErrorf(errs, top, "header places the eagle at %d,%d but the grid has it at %d,%d",
+			l.Eagle[0], l.Eagle[1], top[0], top[1])
+	}
+}
+
+func (l *Level) checkSpawn(g *Grid, sp [2]int, what string, errs *LevelErrors) {
+	if !inFootprintBounds(sp) {
+		errs.errorf(0, 0, "%s %d,%d is out of bounds", what, sp[0], sp[1])
+		return
+	}
+	if !g.IsPassable(sp[0], sp[1]) {
+		l.cellErrorf(errs, sp, "%s %d,%d is blocked", what, sp[0], sp[1])
+	}
+}
+
+// cellErrorf appends an error positioned at a grid cell.
+func (l *Level) cellErrorf(errs *LevelErrors, cell [2]int, format string, args ...any) {
+	errs.errorf(l.LayoutLine+cell[1], cell[0]+1, format, args...)
+}
+
+func inFootprintBounds(p [2]int) bool {
+	return p[0] >= 0 && p[1] >= 0 && p[0] <= config.GridWidth-2 && p[1] <= config.GridHeight-2
+}
@@ -0,0 +1,60 @@
+package world
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		rows   []string
+		want   string // "" for a valid level
+	}{
+		{"valid", "", testRows(), ""},
+		{"misaligned eagle", "",
+			set(set(testRows(), 11, 24, "EE."), 11, 25, "EE."),
+			"eagle at 11,24 is not aligned to the 2x2 block grid"},
+		{"broken eagle", "", set(testRows(), 12, 25, ".."), "eagle must be exactly one 2x2 block of 'E' tiles, found 2 tiles"},
+		{"eagle elsewhere", "eagle: 10,24\n", testRows(), "header places the eagle at 10,24 but the grid has it at 12,24"},
+		{"enemy spawn out of range", "enemy-spawns: 0,0 25,0\n", testRows(), "enemy spawn 25,0 is out of bounds"},
+		{"negative spawn", "enemy-spawns: -2,0\n", testRows(), "enemy spawn -2,0 is out of bounds"},
+		{"player spawn out of range", "player-spawns: 8,24 16,30\n", testRows(), "player 2 spawn 16,30 is out of bounds"},
+		{"blocked spawn", "", set(testRows(), 1, 1, "S"), "enemy spawn 0,0 is blocked"},
+		{"blocked default spawn", "player-spawns: 8,24\n", set(testRows(), 24, 24, "W"), "player 4 spawn 24,24 is blocked"},
+		{"unreachable spawn", "", set(set(testRows(), 0, 2, "SSS"), 2, 0, "S"), "no route from enemy spawn 0,0 to the eagle"},
+	}
+	for _, tc := range tests {
+		l, err := ParseLevel(levelFile(testHeader+tc.header, tc.rows))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		errs := l.Validate()
+		switch {
+		case tc.want == "" && len(errs) > 0:
+			t.Errorf("%s: %v", tc.name, errs)
+		case tc.want != "" && !strings.Contains(errs.Error(), tc.want):
+			t.Errorf("%s: got %q, want %q", tc.name, errs, tc.want)
+		}
+	}
+}
+
+func TestCheckLevel(t *testing.T) {
+	errs := CheckLevel(levelFile(testHeader+"enemy-spawns: 25,0\n", set(testRows(), 4, 1, "X")))
+	if len(errs) != 2 {
+		t.Errorf("got %d errors, want the bad tile and the bad spawn:\n%v", len(errs), errs)
+	}
+}
+
+func TestBuiltinLevelsValidate(t *testing.T) {
+	if len(Levels) == 0 {
+		t.Fatal("no built-in levels")
+	}
+	for i, l := range Levels {
+		if errs := l.Validate(); len(errs) > 0 {
+			t.Errorf("level %d (%s):\n%v", i+1, l.Name, errs)
+		}
+	}
+}